@@ -25,12 +25,21 @@ func (NopLookupReloader) Reload(context.Context, *schedules.Package, string, *ht
 	return nil
 }
 
-type UnconditionalLookupReloader struct{}
+type UnconditionalLookupReloader struct {
+	// Location determines what day "today" falls on; defaults to
+	// time2.PolishTimezone when nil, since that's the operational railway day.
+	Location *time.Location
+}
 
-func (UnconditionalLookupReloader) Reload(ctx context.Context, static *schedules.Package, apikey string, client *http.Client) error {
+func (r UnconditionalLookupReloader) Reload(ctx context.Context, static *schedules.Package, apikey string, client *http.Client) error {
 	slog.Info("Reloading alternative trip lookup table")
 
-	today := time2.Today()
+	loc := r.Location
+	if loc == nil {
+		loc = time2.PolishTimezone
+	}
+
+	today := time2.TodayIn(loc)
 	startDate := today.Previous()
 	endDate := today.Next()
 
@@ -15,8 +15,10 @@ import (
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/match"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/schedules"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/source"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/translate"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/http2"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/secret"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/time2"
 )
 
 var (
@@ -38,7 +40,7 @@ func main() {
 	}
 
 	slog.Info("Loading static schedules")
-	static, err := schedules.LoadGTFSFromPath(*flagGTFS)
+	static, err := schedules.LoadGTFSFromPath(*flagGTFS, time2.PolishTimezone)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -73,14 +75,14 @@ func fetchAlerts(static *schedules.Package, apikey string) (*fact.Container, mat
 	var stats match.Stats
 
 	slog.Debug("Fetching disruptions")
-	real, err := source.FetchDisruptions(context.Background(), apikey, nil)
+	real, err := source.FetchDisruptions(context.Background(), apikey, nil, source.NewFetchOptions())
 	if err != nil {
 		return nil, stats, err
 	}
 	slog.Debug("Fetched disruptions ", "items", len(real.Disruptions))
 
 	slog.Debug("Parsing alerts")
-	facts := match.Alerts(real, static, &stats)
+	facts := match.Alerts(context.Background(), real, static, &stats, translate.PassThrough{}, nil)
 	slog.Debug("Parsed alerts", "facts", len(facts.Alerts), "stats", stats)
 
 	return facts, stats, nil
@@ -90,14 +92,14 @@ func fetchUpdates(static *schedules.Package, apikey string) (*fact.Container, ma
 	var stats match.Stats
 
 	slog.Debug("Fetching operations")
-	real, err := source.FetchOperations(context.Background(), apikey, nil, source.NewPageFetchOptions())
+	real, err := source.FetchOperations(context.Background(), apikey, nil, source.NewFetchOptions())
 	if err != nil {
 		return nil, stats, err
 	}
 	slog.Debug("Fetched operations", "items", len(real.Trains))
 
 	slog.Debug("Parsing trip updates")
-	facts := match.TripUpdates(real, static, &stats)
+	facts := match.TripUpdates(real, static, &stats, match.MatchOptions{})
 	slog.Debug("Parsed trip updates", "facts", len(facts.TripUpdates), "stats", stats)
 
 	return facts, stats, nil
@@ -5,26 +5,61 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/backoff"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/match"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/runner"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/schedules"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/serve"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/source"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/translate"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/http2"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/secret"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/time2"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/text/language"
 )
 
 var (
-	flagAlerts   = flag.Bool("alerts", false, "parse disruptions instead of operations")
-	flagGTFS     = flag.String("gtfs", "polish_trains.zip", "path to GTFS Schedules feed")
-	flagLoop     = flag.Duration("loop", 0, "when non-zero, update the feed continuously with the given period")
-	flagReadable = flag.Bool("readable", false, "dump output in human-readable format")
-	flagVerbose  = flag.Bool("verbose", false, "show DEBUG logging")
+	flagFeeds      = flag.String("feeds", "trip_updates,alerts", "comma-separated list of feeds to produce in a one-shot/-loop run: trip_updates, alerts (ignored in -serve mode, which always runs both on independent schedules)")
+	flagGTFS       = flag.String("gtfs", "polish_trains.zip", "path to GTFS Schedules feed")
+	flagLoop       = flag.Duration("loop", 0, "when non-zero, update the feed continuously with the given period")
+	flagReadable   = flag.Bool("readable", false, "dump output in human-readable format")
+	flagVerbose    = flag.Bool("verbose", false, "show DEBUG logging")
+	flagLanguages  = flag.String("languages", "pl", "comma-separated, most-preferred-first list of BCP 47 language tags used to pick alert text for the JSON files written per -out")
+	flagFiles      = flag.Bool("files", true, "write feed files to disk, per -out")
+	flagOut        = flag.String("out", "polish_trains.{feed}.{ext}", "output path template for one-shot/-loop feed files; {feed} is trip_updates/alerts/vehicle_positions and {ext} is pb/json")
+	flagHTTP       = flag.String("http", "", "when non-empty, serve the feed on this address (e.g. :8080) instead of, or alongside, writing files")
+	flagStaleAfter = flag.Int("stale-after", 3, "how many missed -loop periods before /healthz reports unhealthy; 0 disables the check")
+	flagVehicles   = flag.Bool("vehicles", false, "also emit VehiclePosition entities alongside trip updates")
+	flagTZ         = flag.String("tz", "", "IANA timezone name for the operational railway day (default: TZ env var, or Europe/Warsaw)")
+
+	flagPropagateDelays = flag.Bool("propagate-delays", false, "carry a stop's delay forward onto later stops PKP PLK hasn't reported real-time data for yet, instead of leaving them without a prediction")
+
+	flagServe          = flag.Bool("serve", false, "run as a long-running daemon refreshing trip updates and alerts on independent schedules (-interval, -alerts-interval) and serving both over -http, instead of a single one-shot (or -loop) run of the -feeds selection")
+	flagInterval       = flag.Duration("interval", 30*time.Second, "in -serve mode, how often to refresh trip updates (and vehicle positions, if -vehicles is set)")
+	flagAlertsInterval = flag.Duration("alerts-interval", 2*time.Minute, "in -serve mode, how often to refresh alerts")
+
+	flagArchiveDir       = flag.String("archive-dir", "", "when non-empty, also append every snapshot to this directory (archive/YYYY/MM/DD/HHMMSS.pb)")
+	flagArchiveRetention = flag.Duration("archive-retention", 48*time.Hour, "how long archived snapshots are kept before being pruned")
+	flagArchiveCompress  = flag.Bool("archive-compress", false, "gzip archived snapshots")
+
+	flagTranslateAPI      = flag.String("translate-api", "", "base URL of a LibreTranslate-compatible HTTP API used to translate alert text into -languages; when empty, falls back to -translate-dict or a Polish-only heuristic")
+	flagTranslateDict     = flag.String("translate-dict", "", "path to a JSON dictionary of canned disruption-text translations (see translate.Dictionary), consulted before -translate-api")
+	flagTranslateCacheDir = flag.String("translate-cache-dir", ".translate-cache", "directory where -translate-api results are cached on disk, keyed by a hash of the source text, so restarts don't re-bill the backend")
 )
 
 func main() {
@@ -33,107 +68,304 @@ func main() {
 		slog.SetLogLoggerLevel(slog.LevelDebug)
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	feeds, err := parseFeeds(*flagFeeds)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	preferredLanguages := parseLanguages(*flagLanguages)
+	loc := resolveLocation(*flagTZ)
+
 	apikey, err := secret.FromEnvironment("PKP_PLK_APIKEY")
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	slog.Info("Loading static schedules")
-	static, err := schedules.LoadGTFSFromPath(*flagGTFS)
+	static, err := schedules.LoadGTFSFromPath(*flagGTFS, loc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var archiver *fact.Archiver
+	if *flagArchiveDir != "" {
+		archiver = fact.NewArchiver(*flagArchiveDir, *flagArchiveRetention, *flagArchiveCompress)
+	}
+
+	translateProvider, err := buildTranslateProvider()
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	var httpServer *serve.Server
+	if *flagHTTP != "" {
+		httpServer = serve.NewServer(*flagLoop, *flagStaleAfter)
+		httpServer.Archiver = archiver
+		go func() {
+			slog.Info("Serving GTFS-Realtime feed", "addr", *flagHTTP)
+			if err := http.ListenAndServe(*flagHTTP, httpServer.Mux()); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	r := &runner.Runner{
+		Static:             static,
+		APIKey:             apikey,
+		PreferredLanguages: preferredLanguages,
+		TranslateProvider:  translateProvider,
+		HTTPServer:         httpServer,
+		MatchOptions:       match.MatchOptions{PropagateDelays: *flagPropagateDelays},
+		FetchOptions:       source.NewFetchOptions(),
+		Vehicles:           *flagVehicles,
+		Files:              *flagFiles,
+		Readable:           *flagReadable,
+	}
+
+	if *flagServe {
+		if httpServer == nil {
+			log.Fatal("-serve requires -http")
+		}
+		r.Archiver = archiver
+		httpServer.Period = *flagInterval
+		slog.Info("Serving both feeds", "interval", *flagInterval, "alerts_interval", *flagAlertsInterval)
+		r.Serve(ctx, *flagInterval, *flagAlertsInterval)
+		return
+	}
+
 	if *flagLoop == 0 {
-		totalFacts, stats, err := run(static, apikey)
-		if err != nil {
+		totalFacts, statsByFeed, err := runOnce(ctx, r, archiver, feeds, *flagOut)
+		if err != nil && !errors.Is(err, source.ErrPartialFetch) {
 			log.Fatal(err)
 		}
-		slog.Info("Feed updated successfully", "facts", totalFacts, "stats", stats)
+		if httpServer != nil {
+			updateStats(httpServer, statsByFeed)
+		}
+		logFeedsUpdated(totalFacts, statsByFeed)
 	} else {
 		b := backoff.Backoff{Period: *flagLoop, MaxBackoffExponent: 6}
 		for {
 			b.Wait()
+			if ctx.Err() != nil {
+				slog.Info("Shutting down", "reason", ctx.Err())
+				return
+			}
+
 			b.StartRun()
-			totalFacts, stats, err := run(static, apikey)
-			if err != nil && canBackoff(err) {
+			totalFacts, statsByFeed, err := runOnce(ctx, r, archiver, feeds, *flagOut)
+			if err != nil && errors.Is(err, source.ErrPartialFetch) {
+				slog.Warn("Feed updated from a partial fetch", "error", err, "facts", totalFacts)
+				b.EndRun(backoff.Success)
+			} else if err != nil && canBackoff(err) {
 				nextTry := b.EndRun(backoff.Failure)
 				slog.Error("Feed update failure", "error", err, "next_try", nextTry)
 			} else if err != nil {
 				log.Fatal(err)
 			} else {
 				b.EndRun(backoff.Success)
-				slog.Info("Feed updated successfully", "facts", totalFacts, "stats", stats)
+				logFeedsUpdated(totalFacts, statsByFeed)
+			}
+			if httpServer != nil {
+				updateStats(httpServer, statsByFeed)
+				httpServer.UpdateBackoffFailures(b.Failures)
 			}
 		}
 	}
 }
 
-func run(static *schedules.Package, apikey string) (int, match.Stats, error) {
-	facts, stats, err := fetch(static, apikey)
-	if err != nil {
-		return 0, stats, err
+// runOnce fetches, matches and writes every feed in feeds concurrently via
+// errgroup.Group, sharing r's already-loaded static schedules across all of
+// them. trip_updates also writes a companion VehiclePositions feed when
+// -vehicles is set. outTemplate is expanded per feed/extension by outPath.
+//
+// On a source.ErrPartialFetch (the fetch deadline, or ctx, expired before
+// every page came in for some feed), runOnce still writes whatever was
+// collected for every feed and returns the error alongside the results,
+// rather than discarding mostly-complete snapshots.
+func runOnce(ctx context.Context, r *runner.Runner, archiver *fact.Archiver, feeds []string, outTemplate string) (int, map[string]match.Stats, error) {
+	var (
+		mu          sync.Mutex
+		totalFacts  int
+		statsByFeed = make(map[string]match.Stats, len(feeds))
+	)
+	record := func(feed string, facts int, stats match.Stats) {
+		mu.Lock()
+		defer mu.Unlock()
+		totalFacts += facts
+		statsByFeed[feed] = stats
 	}
 
-	err = writeOutput(facts)
-	return facts.TotalFacts(), stats, err
-}
+	var g errgroup.Group
+	for _, feed := range feeds {
+		switch feed {
+		case "trip_updates":
+			g.Go(func() error {
+				facts, vehicles, stats, err := r.FetchTripUpdates(ctx)
+				if facts == nil {
+					record(feed, 0, stats)
+					return err
+				}
+				if writeErr := r.WriteTripUpdates(facts, outPath(outTemplate, feed, "pb"), outPath(outTemplate, feed, "json"), archiver); writeErr != nil {
+					return writeErr
+				}
+				n := facts.TotalFacts()
 
-func fetch(static *schedules.Package, apikey string) (*fact.Container, match.Stats, error) {
-	if *flagAlerts {
-		return fetchAlerts(static, apikey)
+				if vehicles != nil {
+					if writeErr := r.WriteVehiclePositions(vehicles, outPath(outTemplate, "vehicle_positions", "pb"), outPath(outTemplate, "vehicle_positions", "json")); writeErr != nil {
+						return writeErr
+					}
+					n += vehicles.TotalFacts()
+				}
+
+				record(feed, n, stats)
+				return err
+			})
+		case "alerts":
+			g.Go(func() error {
+				facts, stats, err := r.FetchAlerts(ctx)
+				if facts == nil {
+					record(feed, 0, stats)
+					return err
+				}
+				if writeErr := r.WriteAlerts(facts, outPath(outTemplate, feed, "pb"), outPath(outTemplate, feed, "json"), nil); writeErr != nil {
+					return writeErr
+				}
+				record(feed, facts.TotalFacts(), stats)
+				return err
+			})
+		}
 	}
-	return fetchUpdates(static, apikey)
+
+	err := g.Wait()
+	return totalFacts, statsByFeed, err
 }
 
-func fetchAlerts(static *schedules.Package, apikey string) (*fact.Container, match.Stats, error) {
-	var stats match.Stats
+// outPath expands an -out template for one feed/extension pair, e.g.
+// outPath("polish_trains.{feed}.{ext}", "alerts", "pb") ==
+// "polish_trains.alerts.pb".
+func outPath(template, feed, ext string) string {
+	return strings.NewReplacer("{feed}", feed, "{ext}", ext).Replace(template)
+}
 
-	slog.Debug("Fetching disruptions")
-	real, err := source.FetchDisruptions(context.Background(), apikey, nil)
-	if err != nil {
-		return nil, stats, err
+// parseFeeds turns a comma-separated -feeds flag value into a deduplicated
+// list of known feed names ("trip_updates", "alerts").
+func parseFeeds(s string) ([]string, error) {
+	var feeds []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if part != "trip_updates" && part != "alerts" {
+			return nil, fmt.Errorf("unknown feed %q (expected trip_updates or alerts)", part)
+		}
+		if seen[part] {
+			continue
+		}
+		seen[part] = true
+		feeds = append(feeds, part)
+	}
+	if len(feeds) == 0 {
+		return nil, errors.New("-feeds selects no feeds")
 	}
-	slog.Debug("Fetched disruptions ", "items", len(real.Disruptions))
+	return feeds, nil
+}
 
-	slog.Debug("Parsing alerts")
-	facts := match.Alerts(real, static, &stats)
-	slog.Debug("Parsed alerts", "facts", len(facts.Alerts), "stats", stats)
+// updateStats records the stats of every feed runOnce just produced.
+func updateStats(httpServer *serve.Server, statsByFeed map[string]match.Stats) {
+	if stats, ok := statsByFeed["trip_updates"]; ok {
+		httpServer.UpdateTripUpdateStats(stats)
+	}
+	if stats, ok := statsByFeed["alerts"]; ok {
+		httpServer.UpdateAlertStats(stats)
+	}
+}
 
-	return facts, stats, nil
+// logFeedsUpdated logs one line per feed in statsByFeed, alongside the
+// combined fact count across all of them.
+func logFeedsUpdated(totalFacts int, statsByFeed map[string]match.Stats) {
+	slog.Info("Feeds updated successfully", "facts", totalFacts)
+	for feed, stats := range statsByFeed {
+		slog.Info("Feed stats", "feed", feed, "stats", stats)
+	}
 }
 
-func fetchUpdates(static *schedules.Package, apikey string) (*fact.Container, match.Stats, error) {
-	var stats match.Stats
+// resolveLocation honors an explicit -tz flag, falling back to
+// time2.LocationFromEnv (TZ env var, then Europe/Warsaw) otherwise.
+func resolveLocation(tz string) *time.Location {
+	if tz == "" {
+		return time2.LocationFromEnv()
+	}
 
-	slog.Debug("Fetching operations")
-	real, err := source.FetchOperations(context.Background(), apikey, nil, source.NewPageFetchOptions())
+	loc, err := time.LoadLocation(tz)
 	if err != nil {
-		return nil, stats, err
+		slog.Warn("Ignoring invalid -tz, falling back", "tz", tz, "error", err)
+		return time2.LocationFromEnv()
 	}
-	slog.Debug("Fetched operations", "items", len(real.Trains))
-
-	slog.Debug("Parsing trip updates")
-	facts := match.TripUpdates(real, static, &stats)
-	slog.Debug("Parsed trip updates", "facts", len(facts.TripUpdates), "stats", stats)
-
-	return facts, stats, nil
+	return loc
 }
 
-func writeOutput(facts *fact.Container) error {
-	slog.Debug("Dumping GTFS-Realtime")
-	err := facts.DumpGTFSFile("polish_trains.pb", *flagReadable)
-	if err != nil {
-		return fmt.Errorf("polish_trains.pb: %w", err)
+// buildTranslateProvider assembles the translate.Provider chain driven by
+// -translate-dict and -translate-api: a dictionary lookup (if configured)
+// falling back to an HTTP backend (if configured) falling back to
+// translate.PassThrough, so alert text is always at least in its source
+// language even when every optional backend is disabled or misconfigured.
+func buildTranslateProvider() (translate.Provider, error) {
+	var provider translate.Provider = translate.PassThrough{}
+
+	if *flagTranslateDict != "" {
+		dict, err := translate.LoadDictionary(*flagTranslateDict, provider)
+		if err != nil {
+			return nil, fmt.Errorf("translate-dict: %w", err)
+		}
+		provider = dict
 	}
 
-	slog.Debug("Dumping JSON")
-	err = facts.DumpJSONFile("polish_trains.json", *flagReadable)
-	if err != nil {
-		return fmt.Errorf("polish_trains.json: %w", err)
+	if *flagTranslateAPI != "" {
+		apikey, err := secret.FromEnvironment("TRANSLATE_APIKEY")
+		if err != nil {
+			if _, missing := err.(secret.MissingEnvironmentKey); !missing {
+				return nil, err
+			}
+		}
+		api := &translate.HTTPProvider{
+			BaseURL:  *flagTranslateAPI,
+			APIKey:   apikey,
+			Cache:    translate.NewCache(*flagTranslateCacheDir),
+			Fallback: translate.PassThrough{},
+		}
+		if dict, ok := provider.(*translate.Dictionary); ok {
+			dict.Fallback = api
+		} else {
+			provider = api
+		}
 	}
 
-	return nil
+	return provider, nil
+}
+
+// parseLanguages turns a comma-separated -languages flag value into ordered
+// BCP 47 tags, skipping (and logging) entries that don't parse rather than
+// failing the whole run over an operator typo.
+func parseLanguages(s string) []language.Tag {
+	var tags []language.Tag
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tag, err := language.Parse(part)
+		if err != nil {
+			slog.Warn("Ignoring invalid language tag", "tag", part, "error", err)
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
 }
 
 func canBackoff(err error) bool {
@@ -13,7 +13,9 @@ import (
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/match"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/schedules"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/source"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/translate"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/secret"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/time2"
 )
 
 var (
@@ -29,7 +31,7 @@ func main() {
 	}
 
 	slog.Info("Loading static schedules")
-	static, err := schedules.LoadGTFSFromPath("polish_trains.zip")
+	static, err := schedules.LoadGTFSFromPath("polish_trains.zip", time2.PolishTimezone)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -38,25 +40,25 @@ func main() {
 	var stats match.Stats
 	if *flagAlerts {
 		slog.Info("Fetching disruptions")
-		real, err := source.FetchDisruptions(context.Background(), apikey, nil)
+		real, err := source.FetchDisruptions(context.Background(), apikey, nil, source.NewFetchOptions())
 		if err != nil {
 			log.Fatal(err)
 		}
 		slog.Info("Fetched disruptions ", "items", len(real.Disruptions))
 
 		slog.Info("Parsing alerts")
-		facts = match.Alerts(real, static, &stats)
+		facts = match.Alerts(context.Background(), real, static, &stats, translate.PassThrough{}, nil)
 		slog.Info("Parsed alerts", "facts", len(facts.Alerts), "stats", stats)
 	} else {
 		slog.Info("Fetching operations")
-		real, err := source.FetchOperations(context.Background(), apikey, nil, source.NewPageFetchOptions())
+		real, err := source.FetchOperations(context.Background(), apikey, nil, source.NewFetchOptions())
 		if err != nil {
 			log.Fatal(err)
 		}
 		slog.Info("Fetched operations", "items", len(real.Trains))
 
 		slog.Info("Parsing trip updates")
-		facts = match.TripUpdates(real, static, &stats)
+		facts = match.TripUpdates(real, static, &stats, match.MatchOptions{})
 		slog.Info("Parsed trip updates", "facts", len(facts.TripUpdates), "stats", stats)
 	}
 
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package fact
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveTimeFormat is the canonical string representation of an archived
+// snapshot's timestamp, used in URLs (e.g. GET /archive/{timestamp}.pb).
+const ArchiveTimeFormat = "20060102T150405Z"
+
+// Archiver keeps rotating, timestamped copies of past feed messages on disk,
+// organized as Dir/YYYY/MM/DD/HHMMSS.pb[.gz], so operators can reproduce a
+// matching bug against a known-good snapshot instead of just the latest one.
+type Archiver struct {
+	Dir       string
+	Retention time.Duration
+	Compress  bool
+}
+
+func NewArchiver(dir string, retention time.Duration, compress bool) *Archiver {
+	return &Archiver{Dir: dir, Retention: retention, Compress: compress}
+}
+
+// ArchiveEntry describes one stored snapshot.
+type ArchiveEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	path      string
+}
+
+// Write appends a snapshot of c to the archive and prunes anything older
+// than a.Retention. A no-op when a.Dir is empty.
+func (a *Archiver) Write(c *Container) error {
+	if a.Dir == "" {
+		return nil
+	}
+
+	ts := c.Timestamp.UTC()
+	dir := filepath.Join(a.Dir, fmt.Sprintf("%04d", ts.Year()), fmt.Sprintf("%02d", ts.Month()), fmt.Sprintf("%02d", ts.Day()))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	name := ts.Format("150405") + ".pb"
+	if a.Compress {
+		name += ".gz"
+	}
+
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := a.writeSnapshot(f, c); err != nil {
+		return err
+	}
+
+	return a.prune()
+}
+
+func (a *Archiver) writeSnapshot(f *os.File, c *Container) error {
+	if !a.Compress {
+		return c.DumpGTFS(f, Binary)
+	}
+
+	gw := gzip.NewWriter(f)
+	if err := c.DumpGTFS(gw, Binary); err != nil {
+		gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+// List returns every stored snapshot, oldest first.
+func (a *Archiver) List() ([]ArchiveEntry, error) {
+	var entries []ArchiveEntry
+
+	err := filepath.WalkDir(a.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		ts, ok := entryTimestamp(a.Dir, path)
+		if !ok {
+			return nil
+		}
+
+		entries = append(entries, ArchiveEntry{Timestamp: ts, path: path})
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Open returns a reader for the snapshot stored at exactly ts, decompressing
+// it transparently if it was written with Compress enabled.
+func (a *Archiver) Open(ts time.Time) (io.ReadCloser, error) {
+	entries, err := a.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.Timestamp.Equal(ts) {
+			f, err := os.Open(e.path)
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasSuffix(e.path, ".gz") {
+				gr, err := gzip.NewReader(f)
+				if err != nil {
+					f.Close()
+					return nil, err
+				}
+				return gzipReadCloser{gr, f}, nil
+			}
+			return f, nil
+		}
+	}
+
+	return nil, fs.ErrNotExist
+}
+
+func (a *Archiver) prune() error {
+	if a.Retention <= 0 {
+		return nil
+	}
+
+	entries, err := a.List()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-a.Retention)
+	for _, e := range entries {
+		if e.Timestamp.Before(cutoff) {
+			if err := os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// entryTimestamp recovers the UTC timestamp an archived file was written
+// under, from its Dir-relative YYYY/MM/DD/HHMMSS.pb[.gz] path.
+func entryTimestamp(dir, path string) (time.Time, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	base := strings.TrimSuffix(strings.TrimSuffix(filepath.ToSlash(rel), ".gz"), ".pb")
+	parts := strings.Split(base, "/")
+	if len(parts) != 4 {
+		return time.Time{}, false
+	}
+
+	ts, err := time.ParseInLocation("2006/01/02/150405", strings.Join(parts, "/"), time.UTC)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g gzipReadCloser) Close() error {
+	err := g.Reader.Close()
+	if fErr := g.f.Close(); err == nil {
+		err = fErr
+	}
+	return err
+}
@@ -6,15 +6,18 @@ package fact
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/time2"
 	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"golang.org/x/text/language"
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
 )
@@ -25,10 +28,11 @@ const (
 )
 
 type Container struct {
-	Schema      string        `json:"$schema,omitempty"`
-	Timestamp   time.Time     `json:"timestamp"`
-	Alerts      []*Alert      `json:"alerts,omitempty"`
-	TripUpdates []*TripUpdate `json:"trip_updates,omitempty"`
+	Schema           string             `json:"$schema,omitempty"`
+	Timestamp        time.Time          `json:"timestamp"`
+	Alerts           []*Alert           `json:"alerts,omitempty"`
+	TripUpdates      []*TripUpdate      `json:"trip_updates,omitempty"`
+	VehiclePositions []*VehiclePosition `json:"vehicle_positions,omitempty"`
 }
 
 func (c *Container) AsGTFS() *gtfs.FeedMessage {
@@ -39,26 +43,72 @@ func (c *Container) AsGTFS() *gtfs.FeedMessage {
 		},
 	}
 
-	g.Entity = make([]*gtfs.FeedEntity, 0, len(c.Alerts)+len(c.TripUpdates))
+	g.Entity = make([]*gtfs.FeedEntity, 0, len(c.Alerts)+len(c.TripUpdates)+len(c.VehiclePositions))
 	for _, a := range c.Alerts {
 		g.Entity = append(g.Entity, a.AsGTFS())
 	}
 	for _, u := range c.TripUpdates {
 		g.Entity = append(g.Entity, u.AsGTFS())
 	}
+	for _, v := range c.VehiclePositions {
+		g.Entity = append(g.Entity, v.AsGTFS())
+	}
+
+	return g
+}
+
+// AsGTFSDiff computes a GTFS-Realtime FeedMessage with
+// Header.Incrementality=DIFFERENTIAL, containing only the entities that are
+// new or changed since previous plus a FeedEntity.IsDeleted=true stub for
+// every entity previous had that c no longer does. previous may be nil, in
+// which case every entity in c is reported as changed.
+func (c *Container) AsGTFSDiff(previous *Container) *gtfs.FeedMessage {
+	g := c.AsGTFS()
+	g.Header.Incrementality = ptr(gtfs.FeedHeader_DIFFERENTIAL)
+	if previous == nil {
+		return g
+	}
+
+	prevByID := make(map[string][]byte, previous.TotalFacts())
+	for _, e := range previous.AsGTFS().Entity {
+		if b, err := proto.Marshal(e); err == nil {
+			prevByID[e.GetId()] = b
+		}
+	}
+
+	seen := make(map[string]bool, len(g.Entity))
+	diff := make([]*gtfs.FeedEntity, 0, len(g.Entity))
+	for _, e := range g.Entity {
+		seen[e.GetId()] = true
+		if b, err := proto.Marshal(e); err == nil && bytes.Equal(b, prevByID[e.GetId()]) {
+			continue // unchanged since previous
+		}
+		diff = append(diff, e)
+	}
+	for id := range prevByID {
+		if !seen[id] {
+			diff = append(diff, &gtfs.FeedEntity{Id: ptr(id), IsDeleted: ptr(true)})
+		}
+	}
 
+	g.Entity = diff
 	return g
 }
 
-func (c *Container) DumpJSON(w io.Writer, humanReadable bool) error {
+// DumpJSON encodes the container as JSON. Every Alert is flattened to a
+// single human-readable Title/Message, chosen from its Translations by
+// matching preferred against the languages the alert actually carries; with
+// no preferred tags, the text under the alphabetically first language tag is
+// taken (see Translations.Select).
+func (c *Container) DumpJSON(w io.Writer, humanReadable bool, preferred ...language.Tag) error {
 	e := json.NewEncoder(w)
 	if humanReadable {
 		e.SetIndent("", "\t")
 	}
-	return e.Encode(c)
+	return e.Encode(c.localized(preferred...))
 }
 
-func (c *Container) DumpJSONFile(path string, humanReadable bool) error {
+func (c *Container) DumpJSONFile(path string, humanReadable bool, preferred ...language.Tag) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return err
@@ -66,7 +116,7 @@ func (c *Container) DumpJSONFile(path string, humanReadable bool) error {
 	defer f.Close()
 
 	b := bufio.NewWriter(f)
-	err = c.DumpJSON(b, humanReadable)
+	err = c.DumpJSON(b, humanReadable, preferred...)
 	if err != nil {
 		return err
 	}
@@ -74,6 +124,52 @@ func (c *Container) DumpJSONFile(path string, humanReadable bool) error {
 	return b.Flush()
 }
 
+func (c *Container) localized(preferred ...language.Tag) *localizedContainer {
+	lc := &localizedContainer{
+		Schema:           c.Schema,
+		Timestamp:        c.Timestamp,
+		TripUpdates:      c.TripUpdates,
+		VehiclePositions: c.VehiclePositions,
+	}
+
+	lc.Alerts = make([]*localizedAlert, len(c.Alerts))
+	for i, a := range c.Alerts {
+		lc.Alerts[i] = &localizedAlert{
+			ID:         a.ID,
+			Title:      a.Title.Select(preferred...),
+			Message:    a.Message.Select(preferred...),
+			Trips:      a.Trips,
+			Cause:      a.Cause,
+			Effect:     a.Effect,
+			ActiveFrom: a.ActiveFrom,
+			ActiveTo:   a.ActiveTo,
+		}
+	}
+
+	return lc
+}
+
+// localizedContainer mirrors Container, but with every Alert's Translations
+// collapsed to a single string - the shape written to polish_trains.json.
+type localizedContainer struct {
+	Schema           string             `json:"$schema,omitempty"`
+	Timestamp        time.Time          `json:"timestamp"`
+	Alerts           []*localizedAlert  `json:"alerts,omitempty"`
+	TripUpdates      []*TripUpdate      `json:"trip_updates,omitempty"`
+	VehiclePositions []*VehiclePosition `json:"vehicle_positions,omitempty"`
+}
+
+type localizedAlert struct {
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Message    string            `json:"message"`
+	Trips      []TripSelector    `json:"trips"`
+	Cause      gtfs.Alert_Cause  `json:"cause,omitempty"`
+	Effect     gtfs.Alert_Effect `json:"effect,omitempty"`
+	ActiveFrom time.Time         `json:"active_from,omitzero"`
+	ActiveTo   time.Time         `json:"active_to,omitzero"`
+}
+
 func (c *Container) DumpGTFS(w io.Writer, humanReadable bool) error {
 	var data []byte
 	var err error
@@ -118,14 +214,18 @@ func (c *Container) DumpGTFSFile(path string, humanReadable bool) error {
 }
 
 func (c *Container) TotalFacts() int {
-	return len(c.Alerts) + len(c.TripUpdates)
+	return len(c.Alerts) + len(c.TripUpdates) + len(c.VehiclePositions)
 }
 
 type Alert struct {
-	ID      string         `json:"id"`
-	Title   string         `json:"title"`
-	Message string         `json:"message"`
-	Trips   []TripSelector `json:"trips"`
+	ID         string            `json:"id"`
+	Title      Translations      `json:"title"`
+	Message    Translations      `json:"message"`
+	Trips      []TripSelector    `json:"trips"`
+	Cause      gtfs.Alert_Cause  `json:"cause,omitempty"`
+	Effect     gtfs.Alert_Effect `json:"effect,omitempty"`
+	ActiveFrom time.Time         `json:"active_from,omitzero"`
+	ActiveTo   time.Time         `json:"active_to,omitzero"`
 }
 
 func (a *Alert) AsGTFS() *gtfs.FeedEntity {
@@ -133,12 +233,26 @@ func (a *Alert) AsGTFS() *gtfs.FeedEntity {
 	g.Id = ptr(a.ID)
 	g.Alert = new(gtfs.Alert)
 
-	if a.Title != "" {
-		g.Alert.HeaderText = translatedString(a.Title)
+	if len(a.Title) > 0 {
+		g.Alert.HeaderText = a.Title.AsGTFS()
 	}
 
-	if a.Message != "" {
-		g.Alert.DescriptionText = translatedString(a.Message)
+	if len(a.Message) > 0 {
+		g.Alert.DescriptionText = a.Message.AsGTFS()
+	}
+
+	g.Alert.Cause = ptr(a.Cause)
+	g.Alert.Effect = ptr(a.Effect)
+
+	if !a.ActiveFrom.IsZero() || !a.ActiveTo.IsZero() {
+		tr := new(gtfs.Alert_TimeRange)
+		if !a.ActiveFrom.IsZero() {
+			tr.Start = ptr(uint64(a.ActiveFrom.Unix()))
+		}
+		if !a.ActiveTo.IsZero() {
+			tr.End = ptr(uint64(a.ActiveTo.Unix()))
+		}
+		g.Alert.ActivePeriod = []*gtfs.Alert_TimeRange{tr}
 	}
 
 	g.Alert.InformedEntity = make([]*gtfs.EntitySelector, len(a.Trips))
@@ -149,6 +263,55 @@ func (a *Alert) AsGTFS() *gtfs.FeedEntity {
 	return g
 }
 
+// Translations holds one piece of text per language it is available in,
+// mirroring GTFS-Realtime's TranslatedString. Most Alerts only carry a
+// single, source-language entry; more may be added as translations become
+// available.
+type Translations map[language.Tag]string
+
+func (t Translations) AsGTFS() *gtfs.TranslatedString {
+	tags := t.sortedTags()
+	g := &gtfs.TranslatedString{Translation: make([]*gtfs.TranslatedString_Translation, len(tags))}
+	for i, tag := range tags {
+		g.Translation[i] = &gtfs.TranslatedString_Translation{
+			Text:     ptr(t[tag]),
+			Language: ptr(tag.String()),
+		}
+	}
+	return g
+}
+
+// Select returns the translation best matching preferred, falling back to
+// the text under the alphabetically first language tag when preferred is
+// empty or none of its tags match. Translations does not record which entry
+// is the source-language text, so that fallback is the source text only when
+// the alert carries a single translation.
+func (t Translations) Select(preferred ...language.Tag) string {
+	tags := t.sortedTags()
+	if len(tags) == 0 {
+		return ""
+	}
+
+	if len(preferred) == 0 {
+		return t[tags[0]]
+	}
+
+	_, idx, confidence := language.NewMatcher(tags).Match(preferred...)
+	if confidence == language.No {
+		idx = 0
+	}
+	return t[tags[idx]]
+}
+
+func (t Translations) sortedTags() []language.Tag {
+	tags := make([]language.Tag, 0, len(t))
+	for tag := range t {
+		tags = append(tags, tag)
+	}
+	slices.SortFunc(tags, func(a, b language.Tag) int { return cmp.Compare(a.String(), b.String()) })
+	return tags
+}
+
 type TripUpdate struct {
 	ID string `json:"id"`
 	TripSelector
@@ -183,16 +346,28 @@ func (t *TripUpdate) AsGTFS() *gtfs.FeedEntity {
 }
 
 type StopTimeUpdate struct {
-	Sequence  int       `json:"stop_sequence"`
-	StopID    string    `json:"stop_id,omitempty"`
-	Arrival   time.Time `json:"arrival,omitzero"`
-	Departure time.Time `json:"departure,omitzero"`
-	Cancelled bool      `json:"cancelled,omitempty"`
-	Confirmed bool      `json:"confirmed,omitempty"`
-	Platform  string    `json:"platform,omitempty"`
-	Track     string    `json:"track,omitempty"`
+	Sequence       int       `json:"stop_sequence"`
+	StopID         string    `json:"stop_id,omitempty"`
+	Arrival        time.Time `json:"arrival,omitzero"`
+	Departure      time.Time `json:"departure,omitzero"`
+	ArrivalDelay   *int32    `json:"arrival_delay,omitempty"`
+	DepartureDelay *int32    `json:"departure_delay,omitempty"`
+	Cancelled      bool      `json:"cancelled,omitempty"`
+	Confirmed      bool      `json:"confirmed,omitempty"`
+	Platform       string    `json:"platform,omitempty"`
+	Track          string    `json:"track,omitempty"`
+
+	// Propagated marks a StopTimeUpdate that has no real-time data of its
+	// own: ArrivalDelay/DepartureDelay were carried forward from an earlier
+	// stop by match.MatchOptions.PropagateDelays. AsGTFS reports a bumped
+	// Uncertainty for these, so consumers can tell the ETA is a guess.
+	Propagated bool `json:"propagated,omitempty"`
 }
 
+// propagatedUncertaintyBump is added on top of the usual Uncertainty for a
+// StopTimeUpdate whose delay was propagated rather than observed.
+const propagatedUncertaintyBump = 120
+
 func (s *StopTimeUpdate) AsGTFS() *gtfs.TripUpdate_StopTimeUpdate {
 	g := new(gtfs.TripUpdate_StopTimeUpdate)
 	g.StopSequence = ptr(uint32(s.Sequence))
@@ -207,18 +382,27 @@ func (s *StopTimeUpdate) AsGTFS() *gtfs.TripUpdate_StopTimeUpdate {
 		if s.Confirmed {
 			uncertainty = 0
 		}
+		if s.Propagated {
+			uncertainty += propagatedUncertaintyBump
+		}
 
-		if !s.Arrival.IsZero() {
-			g.Arrival = &gtfs.TripUpdate_StopTimeEvent{
-				Time:        ptr(s.Arrival.Unix()),
-				Uncertainty: ptr(uncertainty),
+		if !s.Arrival.IsZero() || s.ArrivalDelay != nil {
+			g.Arrival = &gtfs.TripUpdate_StopTimeEvent{Uncertainty: ptr(uncertainty)}
+			if !s.Arrival.IsZero() {
+				g.Arrival.Time = ptr(s.Arrival.Unix())
+			}
+			if s.ArrivalDelay != nil {
+				g.Arrival.Delay = ptr(*s.ArrivalDelay)
 			}
 		}
 
-		if !s.Departure.IsZero() {
-			g.Departure = &gtfs.TripUpdate_StopTimeEvent{
-				Time:        ptr(s.Departure.Unix()),
-				Uncertainty: ptr(uncertainty),
+		if !s.Departure.IsZero() || s.DepartureDelay != nil {
+			g.Departure = &gtfs.TripUpdate_StopTimeEvent{Uncertainty: ptr(uncertainty)}
+			if !s.Departure.IsZero() {
+				g.Departure.Time = ptr(s.Departure.Unix())
+			}
+			if s.DepartureDelay != nil {
+				g.Departure.Delay = ptr(*s.DepartureDelay)
 			}
 		}
 	}
@@ -238,19 +422,83 @@ func (s TripSelector) AsGTFS() *gtfs.TripDescriptor {
 	}
 }
 
-func ptr[T any](thing T) *T {
-	return &thing
+// VehiclePosition is the last-known location/stop of a running train, as
+// reported by the PKP PLK operations endpoint.
+type VehiclePosition struct {
+	ID string `json:"id"`
+	TripSelector
+	StopID              string    `json:"stop_id,omitempty"`
+	CurrentStopSequence uint32    `json:"current_stop_sequence,omitempty"`
+	CurrentStatus       string    `json:"current_status,omitempty"`
+	Latitude            float64   `json:"latitude,omitempty"`
+	Longitude           float64   `json:"longitude,omitempty"`
+	Bearing             float64   `json:"bearing,omitempty"`
+	Speed               float64   `json:"speed,omitempty"`
+	OccupancyStatus     string    `json:"occupancy_status,omitempty"`
+	Timestamp           time.Time `json:"timestamp,omitzero"`
 }
 
-func translatedString(s string) *gtfs.TranslatedString {
-	return &gtfs.TranslatedString{
-		Translation: []*gtfs.TranslatedString_Translation{
-			{
-				Text:     ptr(s),
-				Language: ptr("pl"),
-			},
-		},
+var vehicleStopStatus = map[string]gtfs.VehiclePosition_VehicleStopStatus{
+	"INCOMING_AT":   gtfs.VehiclePosition_INCOMING_AT,
+	"STOPPED_AT":    gtfs.VehiclePosition_STOPPED_AT,
+	"IN_TRANSIT_TO": gtfs.VehiclePosition_IN_TRANSIT_TO,
+}
+
+var vehicleOccupancyStatus = map[string]gtfs.VehiclePosition_OccupancyStatus{
+	"EMPTY":                      gtfs.VehiclePosition_EMPTY,
+	"MANY_SEATS_AVAILABLE":       gtfs.VehiclePosition_MANY_SEATS_AVAILABLE,
+	"FEW_SEATS_AVAILABLE":        gtfs.VehiclePosition_FEW_SEATS_AVAILABLE,
+	"STANDING_ROOM_ONLY":         gtfs.VehiclePosition_STANDING_ROOM_ONLY,
+	"CRUSHED_STANDING_ROOM_ONLY": gtfs.VehiclePosition_CRUSHED_STANDING_ROOM_ONLY,
+	"FULL":                       gtfs.VehiclePosition_FULL,
+	"NOT_ACCEPTING_PASSENGERS":   gtfs.VehiclePosition_NOT_ACCEPTING_PASSENGERS,
+}
+
+func (v *VehiclePosition) AsGTFS() *gtfs.FeedEntity {
+	g := new(gtfs.FeedEntity)
+	g.Id = ptr(v.ID)
+	g.Vehicle = new(gtfs.VehiclePosition)
+	g.Vehicle.Trip = v.TripSelector.AsGTFS()
+
+	if v.StopID != "" {
+		g.Vehicle.StopId = ptr(v.StopID)
 	}
+
+	if v.CurrentStopSequence != 0 {
+		g.Vehicle.CurrentStopSequence = ptr(v.CurrentStopSequence)
+	}
+
+	if status, ok := vehicleStopStatus[v.CurrentStatus]; ok {
+		g.Vehicle.CurrentStatus = ptr(status)
+	}
+
+	if v.Latitude != 0 || v.Longitude != 0 {
+		pos := &gtfs.Position{
+			Latitude:  ptr(float32(v.Latitude)),
+			Longitude: ptr(float32(v.Longitude)),
+		}
+		if v.Bearing != 0 {
+			pos.Bearing = ptr(float32(v.Bearing))
+		}
+		if v.Speed != 0 {
+			pos.Speed = ptr(float32(v.Speed))
+		}
+		g.Vehicle.Position = pos
+	}
+
+	if status, ok := vehicleOccupancyStatus[v.OccupancyStatus]; ok {
+		g.Vehicle.OccupancyStatus = ptr(status)
+	}
+
+	if !v.Timestamp.IsZero() {
+		g.Vehicle.Timestamp = ptr(uint64(v.Timestamp.Unix()))
+	}
+
+	return g
+}
+
+func ptr[T any](thing T) *T {
+	return &thing
 }
 
 func getTempOutputPath(path string) string {
@@ -4,31 +4,55 @@
 package match
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/schedules"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/source"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/translate"
+	"golang.org/x/text/language"
 )
 
-func Alerts(real *source.Disruptions, static *schedules.Package) *fact.Container {
+// Alerts matches every Disruption against static, translating title/message
+// text into targets via provider. provider may be nil, in which case
+// translation falls back to the single-language detectLanguage heuristic.
+func Alerts(ctx context.Context, real *source.Disruptions, static *schedules.Package, stats *Stats, provider translate.Provider, targets []language.Tag) *fact.Container {
 	c := &fact.Container{
 		Timestamp: real.Timestamp,
 		Alerts:    make([]*fact.Alert, 0, len(real.Disruptions)),
 	}
 	for _, d := range real.Disruptions {
-		if a := Alert(d, static); a != nil {
+		if ctx.Err() != nil {
+			break
+		}
+		if a := Alert(ctx, d, static, stats, provider, targets); a != nil {
 			c.Alerts = append(c.Alerts, a)
 		}
 	}
 	return c
 }
 
-func Alert(real *source.Disruption, static *schedules.Package) *fact.Alert {
+func Alert(ctx context.Context, real *source.Disruption, static *schedules.Package, stats *Stats, provider translate.Provider, targets []language.Tag) *fact.Alert {
 	// Try to match the trains
 	trips := make([]fact.TripSelector, 0, len(real.AffectedTrains))
 	for _, train := range real.AffectedTrains {
-		trips = append(trips, TripSelectors(train.TrainID, static)...)
+		// Disruptions only report a single affected station, not which end
+		// of the run it is, so it's only usable as a stop-membership check.
+		hint := schedules.LookupHint{FirstStopID: static.Stops[strconv.Itoa(train.StationID)], Date: train.OperatingDate}
+		trips = append(trips, TripSelectors(train.TrainID, hint, static)...)
+	}
+
+	if stats != nil {
+		if len(trips) > 0 {
+			stats.Matched++
+		} else {
+			stats.Unmatched++
+		}
 	}
 
 	// Bail out when no trains match
@@ -37,10 +61,84 @@ func Alert(real *source.Disruption, static *schedules.Package) *fact.Alert {
 	}
 
 	// Convert the alert
+	cause, effect := causeEffect(real.Type)
+	activeFrom, activeTo := activePeriod(real.ActivePeriod, static)
 	return &fact.Alert{
-		ID:      fmt.Sprintf("A_%d", real.ID),
-		Title:   real.Title,
-		Message: real.Message,
-		Trips:   trips,
+		ID:         fmt.Sprintf("A_%d", real.ID),
+		Title:      translateText(ctx, real.Title, provider, targets),
+		Message:    translateText(ctx, real.Message, provider, targets),
+		Trips:      trips,
+		Cause:      cause,
+		Effect:     effect,
+		ActiveFrom: activeFrom,
+		ActiveTo:   activeTo,
+	}
+}
+
+// activePeriod turns a source.Period into a [from, to) instant range in
+// static's operational timezone, so GTFS-RT consumers can suppress alerts
+// outside it. Either return value is the zero time.Time when p doesn't
+// carry a valid bound on that end.
+func activePeriod(p source.Period, static *schedules.Package) (from, to time.Time) {
+	if p.From.IsValid() {
+		from = p.From.StartOfDay(static.Location)
+	}
+	if p.To.IsValid() {
+		to = p.To.Next().StartOfDay(static.Location)
+	}
+	return
+}
+
+// translateText runs s through provider, guessing its source language with
+// detectLanguage. When provider is nil, or returns any error (ctx
+// cancellation included), it falls back to taggedTranslation so callers
+// always get usable (if not fully multilingual) text.
+func translateText(ctx context.Context, s string, provider translate.Provider, targets []language.Tag) fact.Translations {
+	if s == "" {
+		return nil
+	}
+	if provider == nil {
+		return taggedTranslation(s)
+	}
+
+	t, err := provider.Translate(ctx, s, detectLanguage(s), targets...)
+	if err != nil {
+		slog.Warn("Falling back to source-language text", "error", err)
+		return taggedTranslation(s)
+	}
+	return t
+}
+
+// taggedTranslation wraps s into a single-entry fact.Translations map, tagged
+// with a best-effort guess at its language. PKP PLK disruption texts are
+// overwhelmingly Polish, but some are written in English; detectLanguage is
+// a crude heuristic, not a real language identifier.
+func taggedTranslation(s string) fact.Translations {
+	if s == "" {
+		return nil
+	}
+	return fact.Translations{detectLanguage(s): s}
+}
+
+// polishLetters are the Latin letters with diacritics unique to Polish
+// orthography; their presence is a reliable signal that s is Polish.
+const polishLetters = "ąćęłńóśźżĄĆĘŁŃÓŚŹŻ"
+
+// englishWords are common English words and railway terms that don't
+// otherwise appear in Polish railway disruption texts.
+var englishWords = []string{"the", "and", "due to", "station", "train", "delay", "closed"}
+
+func detectLanguage(s string) language.Tag {
+	if strings.ContainsAny(s, polishLetters) {
+		return language.Polish
 	}
+
+	lower := strings.ToLower(s)
+	for _, word := range englishWords {
+		if strings.Contains(lower, word) {
+			return language.English
+		}
+	}
+
+	return language.Polish
 }
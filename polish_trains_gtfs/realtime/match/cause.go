@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package match
+
+import (
+	"log/slog"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+)
+
+// causeEffectByType maps a PLK disruption Type code to the GTFS-Realtime
+// Cause/Effect pair it's reported as. Codes not present here are logged and
+// reported as UNKNOWN_CAUSE/UNKNOWN_EFFECT rather than silently guessed at,
+// so a new PLK code gets triaged instead of miscategorized forever.
+var causeEffectByType = map[string]struct {
+	Cause  gtfs.Alert_Cause
+	Effect gtfs.Alert_Effect
+}{
+	"STRIKE":       {gtfs.Alert_STRIKE, gtfs.Alert_NO_SERVICE},
+	"WEATHER":      {gtfs.Alert_WEATHER, gtfs.Alert_REDUCED_SERVICE},
+	"CONSTRUCTION": {gtfs.Alert_CONSTRUCTION, gtfs.Alert_DETOUR},
+	"ACCIDENT":     {gtfs.Alert_ACCIDENT, gtfs.Alert_SIGNIFICANT_DELAYS},
+}
+
+// causeEffect returns the Cause/Effect pair for a PLK disruption Type code,
+// falling back to UNKNOWN_CAUSE/UNKNOWN_EFFECT (and a log line) for codes
+// causeEffectByType doesn't know about yet.
+func causeEffect(typeCode string) (gtfs.Alert_Cause, gtfs.Alert_Effect) {
+	if ce, ok := causeEffectByType[typeCode]; ok {
+		return ce.Cause, ce.Effect
+	}
+	slog.Warn("Unmapped PLK disruption type code", "type", typeCode)
+	return gtfs.Alert_UNKNOWN_CAUSE, gtfs.Alert_UNKNOWN_EFFECT
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package match
+
+import (
+	"testing"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+)
+
+// seenPLKDisruptionTypes lists every Disruption.Type code observed in
+// captured PKP PLK fixtures. When PLK starts reporting a new type, add it
+// here; causeEffectByType must be updated to match, or this test fails
+// instead of the new code silently falling back to UNKNOWN_CAUSE.
+var seenPLKDisruptionTypes = []string{
+	"STRIKE",
+	"WEATHER",
+	"CONSTRUCTION",
+	"ACCIDENT",
+}
+
+func TestCauseEffect_KnownTypesAreMapped(t *testing.T) {
+	for _, typeCode := range seenPLKDisruptionTypes {
+		if _, ok := causeEffectByType[typeCode]; !ok {
+			t.Errorf("causeEffectByType has no entry for seen PLK type %q", typeCode)
+		}
+	}
+}
+
+func TestCauseEffect(t *testing.T) {
+	tests := []struct {
+		typeCode string
+		cause    gtfs.Alert_Cause
+		effect   gtfs.Alert_Effect
+	}{
+		{"STRIKE", gtfs.Alert_STRIKE, gtfs.Alert_NO_SERVICE},
+		{"WEATHER", gtfs.Alert_WEATHER, gtfs.Alert_REDUCED_SERVICE},
+		{"CONSTRUCTION", gtfs.Alert_CONSTRUCTION, gtfs.Alert_DETOUR},
+		{"ACCIDENT", gtfs.Alert_ACCIDENT, gtfs.Alert_SIGNIFICANT_DELAYS},
+	}
+
+	for _, tt := range tests {
+		cause, effect := causeEffect(tt.typeCode)
+		if cause != tt.cause || effect != tt.effect {
+			t.Errorf("causeEffect(%q) = (%v, %v), want (%v, %v)", tt.typeCode, cause, effect, tt.cause, tt.effect)
+		}
+	}
+}
+
+func TestCauseEffect_UnmappedTypeFallsBackToUnknown(t *testing.T) {
+	cause, effect := causeEffect("SOME_NEW_PLK_TYPE")
+	if cause != gtfs.Alert_UNKNOWN_CAUSE || effect != gtfs.Alert_UNKNOWN_EFFECT {
+		t.Errorf("causeEffect(unmapped) = (%v, %v), want (UNKNOWN_CAUSE, UNKNOWN_EFFECT)", cause, effect)
+	}
+}
@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package match
+
+// MatchOptions tweaks optional, opt-in behavior of TripUpdates/TripUpdate.
+// The zero value reproduces the pre-existing behavior.
+type MatchOptions struct {
+	// PropagateDelays carries a stop's delay forward onto subsequent stops
+	// that have no real-time data of their own, instead of leaving them
+	// without a prediction entirely. Propagated StopTimeUpdates are
+	// reported with a bumped Uncertainty, so consumers can still tell the
+	// ETA is a guess rather than an observed delay.
+	PropagateDelays bool
+}
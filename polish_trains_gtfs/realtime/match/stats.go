@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package match
+
+// Stats accounts for how the real-time trains/disruptions polled from PKP
+// PLK were resolved against the static schedule during a single run.
+type Stats struct {
+	Matched          int
+	Unmatched        int
+	OutsideFeedDates int
+}
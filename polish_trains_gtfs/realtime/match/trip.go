@@ -4,22 +4,39 @@
 package match
 
 import (
+	"cmp"
+	"slices"
+	"strconv"
+
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/schedules"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/source"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/time2"
 )
 
-func Trip(real source.TrainID, static *schedules.Package) *schedules.Trip {
+// Trip resolves real to a schedule Trip, first by its exact (schedule,
+// order, PLK start date) identity, then - if that identity isn't known
+// directly but was seen under a different train number on a previous
+// Reload - by falling back to static.AlternativeTripLookup and
+// disambiguating same-numbered candidates with hint.
+func Trip(real source.TrainID, hint schedules.LookupHint, static *schedules.Package) *schedules.Trip {
 	id := schedules.TripID{
 		ScheduleID:   real.ScheduleID,
 		OrderID:      real.OrderID,
 		PLKStartDate: real.OperatingDate,
 	}
-	return static.Trips[id]
+	if t := static.Trips[id]; t != nil {
+		return t
+	}
+
+	if number, ok := static.AlternativeTripLookup[id]; ok {
+		return static.LookupByNumber(number, hint)
+	}
+	return nil
 }
 
-func TripSelectors(real source.TrainID, static *schedules.Package) []fact.TripSelector {
-	t := Trip(real, static)
+func TripSelectors(real source.TrainID, hint schedules.LookupHint, static *schedules.Package) []fact.TripSelector {
+	t := Trip(real, hint, static)
 	if t == nil {
 		return nil
 	}
@@ -31,3 +48,30 @@ func TripSelectors(real source.TrainID, static *schedules.Package) []fact.TripSe
 	}
 	return selectors
 }
+
+// hintFromStops builds a schedules.LookupHint out of PKP PLK's live stop
+// reports, for disambiguating same-numbered trains via
+// schedules.Package.LookupByNumber. Stops that canonicalStops doesn't
+// recognize are skipped.
+func hintFromStops(date time2.Date, stops []*source.OperationTrainStop, canonicalStops map[string]string) schedules.LookupHint {
+	hint := schedules.LookupHint{Date: date}
+
+	ordered := make([]*source.OperationTrainStop, len(stops))
+	copy(ordered, stops)
+	slices.SortFunc(ordered, func(a, b *source.OperationTrainStop) int {
+		return cmp.Compare(a.PlannedSequence, b.PlannedSequence)
+	})
+
+	for _, s := range ordered {
+		id := canonicalStops[strconv.Itoa(s.StopID)]
+		if id == "" {
+			continue
+		}
+		if hint.FirstStopID == "" {
+			hint.FirstStopID = id
+		}
+		hint.LastStopID = id
+	}
+
+	return hint
+}
@@ -17,22 +17,22 @@ import (
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/time2"
 )
 
-func TripUpdates(real *source.Operations, static *schedules.Package, stats *Stats) *fact.Container {
+func TripUpdates(real *source.Operations, static *schedules.Package, stats *Stats, opts MatchOptions) *fact.Container {
 	c := &fact.Container{
 		Schema:      "https://mkuran.pl/gtfs/polish_trains/live.schema.json",
 		Timestamp:   real.Timestamp.In(time2.PolishTimezone),
 		TripUpdates: make([]*fact.TripUpdate, 0, len(real.Trains)),
 	}
 	for _, t := range real.Trains {
-		if u := TripUpdate(t, static, stats); u != nil {
+		if u := TripUpdate(t, static, stats, opts); u != nil {
 			c.TripUpdates = append(c.TripUpdates, u...)
 		}
 	}
 	return c
 }
 
-func TripUpdate(real *source.OperationTrain, static *schedules.Package, stats *Stats) []*fact.TripUpdate {
-	trip := Trip(real.TrainID, static)
+func TripUpdate(real *source.OperationTrain, static *schedules.Package, stats *Stats, opts MatchOptions) []*fact.TripUpdate {
+	trip := Trip(real.TrainID, hintFromStops(real.OperatingDate, real.Stops, static.Stops), static)
 	if stats != nil {
 		if trip != nil {
 			stats.Matched++
@@ -87,7 +87,10 @@ func TripUpdate(real *source.OperationTrain, static *schedules.Package, stats *S
 		updates[i] = newTripUpdate(trip, tripID)
 	}
 
-	// Generate stop-time updates
+	// Generate stop-time updates, carrying the last observed delay per
+	// trip id forward so it can be propagated onto stops lacking real-time
+	// data, when opts.PropagateDelays is set.
+	lastDelay := make([]*int32, len(tripIDs))
 	for _, st := range trip.StopTimes {
 		i, ok := updateIndexByTripID[st.GTFSTripID]
 		if !ok {
@@ -95,26 +98,57 @@ func TripUpdate(real *source.OperationTrain, static *schedules.Package, stats *S
 		}
 
 		realUpdate := realStopByPLKSequence[st.PLKSequence]
-		if realUpdate == nil {
-			continue
-		}
-
 		update := &fact.StopTimeUpdate{Sequence: st.GTFSSequence}
-		if realUpdate.Cancelled {
+
+		switch {
+		case realUpdate != nil && realUpdate.Cancelled:
 			update.Cancelled = true
-		} else {
+			lastDelay[i] = nil // don't leak a delay across a skipped stop
+
+		case realUpdate != nil:
 			update.Confirmed = realUpdate.Confirmed
 			update.Arrival = time.Time(realUpdate.LiveArrival)
 			update.Departure = time.Time(realUpdate.LiveDeparture)
 			update.Platform = st.Platform
 			update.Track = st.Track
+			update.ArrivalDelay = delayAt(trip.GTFSStartDate, st.ScheduledArrival, update.Arrival, static.Location)
+			update.DepartureDelay = delayAt(trip.GTFSStartDate, st.ScheduledDeparture, update.Departure, static.Location)
+			if update.DepartureDelay != nil {
+				lastDelay[i] = update.DepartureDelay
+			} else if update.ArrivalDelay != nil {
+				lastDelay[i] = update.ArrivalDelay
+			}
+
+		case opts.PropagateDelays && lastDelay[i] != nil:
+			update.Platform = st.Platform
+			update.Track = st.Track
+			update.ArrivalDelay = lastDelay[i]
+			update.DepartureDelay = lastDelay[i]
+			update.Propagated = true
+
+		default:
+			continue // no real data for this stop, and nothing to propagate
 		}
+
 		updates[i].StopTimes = append(updates[i].StopTimes, update)
 	}
 
 	return updates
 }
 
+// delayAt returns how many seconds actual arrived/departed after the
+// scheduled instant derived from start plus secondsPastMidnight, or nil when
+// the schedule doesn't carry a time for this event (secondsPastMidnight < 0)
+// or actual itself is unknown.
+func delayAt(start time2.Date, secondsPastMidnight int, actual time.Time, loc *time.Location) *int32 {
+	if secondsPastMidnight < 0 || actual.IsZero() {
+		return nil
+	}
+	scheduled := start.StartOfDay(loc).Add(time.Duration(secondsPastMidnight) * time.Second)
+	delay := int32(actual.Sub(scheduled).Seconds())
+	return &delay
+}
+
 func isEntireTripCancelled(real *source.OperationTrain) bool {
 	if len(real.Stops) == 0 {
 		return real.Status == "X"
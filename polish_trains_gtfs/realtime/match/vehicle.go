@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package match
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/schedules"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/source"
+)
+
+func VehiclePositions(real *source.Operations, static *schedules.Package, stats *Stats) *fact.Container {
+	c := &fact.Container{
+		Timestamp:        real.Timestamp,
+		VehiclePositions: make([]*fact.VehiclePosition, 0, len(real.Trains)),
+	}
+	for _, t := range real.Trains {
+		if vp := VehiclePosition(t, static, stats); vp != nil {
+			c.VehiclePositions = append(c.VehiclePositions, vp)
+		}
+	}
+	return c
+}
+
+func VehiclePosition(real *source.OperationTrain, static *schedules.Package, stats *Stats) *fact.VehiclePosition {
+	trip := Trip(real.TrainID, hintFromStops(real.OperatingDate, real.Stops, static.Stops), static)
+	if stats != nil {
+		if trip != nil {
+			stats.Matched++
+		} else if !static.Dates.Contains(real.OperatingDate) {
+			stats.OutsideFeedDates++
+		} else {
+			stats.Unmatched++
+		}
+	}
+
+	if trip == nil {
+		return nil
+	}
+	tripIDs := trip.GetTripIDs()
+	if len(tripIDs) == 0 {
+		return nil
+	}
+	selector := fact.TripSelector{TripID: tripIDs[0], GTFSStartDate: trip.GTFSStartDate}
+
+	stop, status := latestKnownStop(real.Stops)
+
+	// PKP PLK's operations endpoint doesn't expose live coordinates, bearing
+	// or speed, so a VehiclePosition only ever carries the last confirmed
+	// stop; Latitude/Longitude/Bearing/Speed are left at their zero value
+	// and omitted from the output.
+	vp := &fact.VehiclePosition{
+		ID:            fmt.Sprintf("V_%s_%s", selector.GTFSStartDate, selector.TripID),
+		TripSelector:  selector,
+		CurrentStatus: status,
+	}
+	if stop != nil {
+		vp.StopID = static.Stops[strconv.Itoa(stop.StopID)]
+		vp.CurrentStopSequence = uint32(currentStopSequence(trip, selector.TripID, stop.PlannedSequence))
+	}
+	return vp
+}
+
+// currentStopSequence returns the GTFS stop_sequence of the StopTime on trip
+// with the given GTFS trip ID and PLK sequence number, or zero if unknown.
+func currentStopSequence(trip *schedules.Trip, tripID string, plkSequence int) int {
+	for _, st := range trip.StopTimes {
+		if st.GTFSTripID == tripID && st.PLKSequence == plkSequence {
+			return st.GTFSSequence
+		}
+	}
+	return 0
+}
+
+// latestKnownStop picks the most recently reached, non-cancelled stop
+// reported by PKP PLK for a train, and whether it is still sitting there or
+// already on its way to the next one.
+func latestKnownStop(stops []*source.OperationTrainStop) (latest *source.OperationTrainStop, status string) {
+	for _, s := range stops {
+		if s.Cancelled || !s.Confirmed {
+			continue
+		}
+		if latest == nil || s.ActualSequence > latest.ActualSequence {
+			latest = s
+		}
+	}
+	if latest == nil {
+		return nil, ""
+	}
+
+	status = "STOPPED_AT"
+	if !time.Time(latest.LiveDeparture).IsZero() {
+		status = "IN_TRANSIT_TO"
+	}
+	return
+}
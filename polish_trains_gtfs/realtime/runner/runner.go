@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+// Package runner holds the fetch/match/dump pipeline shared by the one-shot
+// and -serve (daemon) modes of cmd/main.go, so both invoke the exact same
+// code to go from a PKP PLK poll to a published fact.Container.
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/match"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/schedules"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/serve"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/source"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/translate"
+	"golang.org/x/text/language"
+)
+
+// Runner fetches, matches and publishes PKP PLK data against a single
+// loaded schedules.Package. It's built once from parsed flags and reused
+// for every poll, whether driven by a one-shot run, -loop, or -serve.
+type Runner struct {
+	Static             *schedules.Package
+	APIKey             string
+	Client             *http.Client
+	PreferredLanguages []language.Tag
+	TranslateProvider  translate.Provider
+	Archiver           *fact.Archiver
+	HTTPServer         *serve.Server
+	MatchOptions       match.MatchOptions
+	FetchOptions       source.FetchOptions
+
+	// Vehicles, when set, makes FetchTripUpdates also derive a companion
+	// VehiclePositions feed from the same operations poll.
+	Vehicles bool
+
+	// Files, when set, makes Write* dump every feed to disk in addition to
+	// (or instead of, if HTTPServer is nil) publishing it over HTTP.
+	Files bool
+
+	// Readable selects human-readable prototext/indented-JSON output for
+	// every Write* call.
+	Readable bool
+}
+
+// FetchTripUpdates polls PKP PLK operations and matches them against
+// r.Static. vehicles is nil unless r.Vehicles is set.
+//
+// err may be a non-nil wrapping source.ErrPartialFetch even when facts is
+// returned: that means the fetch deadline expired before every operations
+// page came in, but facts was still matched from the pages that did. The
+// caller decides whether to publish it anyway.
+func (r *Runner) FetchTripUpdates(ctx context.Context) (facts, vehicles *fact.Container, stats match.Stats, err error) {
+	slog.Debug("Fetching operations")
+	real, err := source.FetchOperations(ctx, r.APIKey, r.Client, r.FetchOptions)
+	if real == nil {
+		return nil, nil, stats, err
+	}
+	if err != nil {
+		slog.Warn("Matching a partial operations snapshot", "error", err)
+	}
+	slog.Debug("Fetched operations", "items", len(real.Trains))
+
+	slog.Debug("Parsing trip updates")
+	facts = match.TripUpdates(real, r.Static, &stats, r.MatchOptions)
+	slog.Debug("Parsed trip updates", "facts", len(facts.TripUpdates), "stats", stats)
+
+	if r.Vehicles {
+		slog.Debug("Parsing vehicle positions")
+		vehicles = match.VehiclePositions(real, r.Static, nil)
+		slog.Debug("Parsed vehicle positions", "facts", len(vehicles.VehiclePositions))
+	}
+
+	return facts, vehicles, stats, err
+}
+
+// FetchAlerts polls PKP PLK disruptions and matches/translates them against
+// r.Static.
+func (r *Runner) FetchAlerts(ctx context.Context) (*fact.Container, match.Stats, error) {
+	var stats match.Stats
+
+	slog.Debug("Fetching disruptions")
+	real, err := source.FetchDisruptions(ctx, r.APIKey, r.Client, r.FetchOptions)
+	if err != nil {
+		return nil, stats, err
+	}
+	slog.Debug("Fetched disruptions", "items", len(real.Disruptions))
+
+	slog.Debug("Parsing alerts")
+	facts := match.Alerts(ctx, real, r.Static, &stats, r.TranslateProvider, r.PreferredLanguages)
+	slog.Debug("Parsed alerts", "facts", len(facts.Alerts), "stats", stats)
+
+	return facts, stats, nil
+}
+
+// WriteTripUpdates publishes facts to r.HTTPServer (when set) and, when
+// r.Files is set, dumps it to pbPath/jsonPath and, when archiver is set,
+// appends it there.
+func (r *Runner) WriteTripUpdates(facts *fact.Container, pbPath, jsonPath string, archiver *fact.Archiver) error {
+	if r.HTTPServer != nil {
+		r.HTTPServer.UpdateTripUpdates(facts)
+	}
+	return r.writeFiles(facts, pbPath, jsonPath, archiver)
+}
+
+// WriteAlerts publishes facts to r.HTTPServer (when set) and, when r.Files
+// is set, dumps it to pbPath/jsonPath and, when archiver is set, appends it
+// there.
+func (r *Runner) WriteAlerts(facts *fact.Container, pbPath, jsonPath string, archiver *fact.Archiver) error {
+	if r.HTTPServer != nil {
+		r.HTTPServer.UpdateAlerts(facts)
+	}
+	return r.writeFiles(facts, pbPath, jsonPath, archiver)
+}
+
+// WriteVehiclePositions publishes vehicles to r.HTTPServer (when set) and,
+// when r.Files is set, dumps it to pbPath/jsonPath.
+func (r *Runner) WriteVehiclePositions(vehicles *fact.Container, pbPath, jsonPath string) error {
+	if r.HTTPServer != nil {
+		r.HTTPServer.UpdateVehicles(vehicles)
+	}
+	return r.writeFiles(vehicles, pbPath, jsonPath, nil)
+}
+
+func (r *Runner) writeFiles(facts *fact.Container, pbPath, jsonPath string, archiver *fact.Archiver) error {
+	if !r.Files {
+		return nil
+	}
+
+	slog.Debug("Dumping GTFS-Realtime", "path", pbPath)
+	if err := facts.DumpGTFSFile(pbPath, r.Readable); err != nil {
+		return fmt.Errorf("%s: %w", pbPath, err)
+	}
+
+	slog.Debug("Dumping JSON", "path", jsonPath)
+	if err := facts.DumpJSONFile(jsonPath, r.Readable, r.PreferredLanguages...); err != nil {
+		return fmt.Errorf("%s: %w", jsonPath, err)
+	}
+
+	if archiver != nil {
+		slog.Debug("Archiving snapshot")
+		if err := archiver.Write(facts); err != nil {
+			return fmt.Errorf("archive: %w", err)
+		}
+	}
+
+	return nil
+}
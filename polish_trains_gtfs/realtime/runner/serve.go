@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/backoff"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/match"
+)
+
+// Serve runs r.ServeTripUpdates and r.ServeAlerts concurrently, each on its
+// own refresh period, until ctx is done. It blocks until both have
+// returned, which only happens once ctx is cancelled.
+func (r *Runner) Serve(ctx context.Context, tripUpdatesInterval, alertsInterval time.Duration) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); r.ServeTripUpdates(ctx, tripUpdatesInterval) }()
+	go func() { defer wg.Done(); r.ServeAlerts(ctx, alertsInterval) }()
+	wg.Wait()
+}
+
+// ServeTripUpdates refreshes trip updates (and, when r.Vehicles is set,
+// vehicle positions) every interval until ctx is done, publishing each
+// result to r.HTTPServer and, when r.Files is set, to disk and r.Archiver.
+func (r *Runner) ServeTripUpdates(ctx context.Context, interval time.Duration) {
+	r.loop(ctx, interval, func(ctx context.Context) (match.Stats, error) {
+		facts, vehicles, stats, fetchErr := r.FetchTripUpdates(ctx)
+		if facts == nil {
+			return stats, fetchErr
+		}
+		if err := r.WriteTripUpdates(facts, "polish_trains.pb", "polish_trains.json", r.Archiver); err != nil {
+			return stats, err
+		}
+		if vehicles != nil {
+			if err := r.WriteVehiclePositions(vehicles, "polish_trains_vp.pb", "polish_trains_vp.json"); err != nil {
+				return stats, err
+			}
+		}
+		if r.HTTPServer != nil {
+			r.HTTPServer.UpdateTripUpdateStats(stats)
+		}
+		slog.Info("Trip updates refreshed", "facts", facts.TotalFacts(), "stats", stats)
+		return stats, fetchErr
+	})
+}
+
+// ServeAlerts refreshes alerts every interval until ctx is done, publishing
+// each result to r.HTTPServer. Unlike trip updates, alerts are never
+// appended to r.Archiver here: Archiver names snapshots by second-resolution
+// timestamp, and ServeTripUpdates and ServeAlerts run on independent
+// schedules in the same process, so sharing one archive between them would
+// risk one feed's snapshot clobbering the other's.
+func (r *Runner) ServeAlerts(ctx context.Context, interval time.Duration) {
+	r.loop(ctx, interval, func(ctx context.Context) (match.Stats, error) {
+		facts, stats, err := r.FetchAlerts(ctx)
+		if err != nil {
+			return stats, err
+		}
+		if err := r.WriteAlerts(facts, "polish_trains_alerts.pb", "polish_trains_alerts.json", nil); err != nil {
+			return stats, err
+		}
+		if r.HTTPServer != nil {
+			r.HTTPServer.UpdateAlertStats(stats)
+		}
+		slog.Info("Alerts refreshed", "facts", facts.TotalFacts(), "stats", stats)
+		return stats, nil
+	})
+}
+
+// loop calls run every period until ctx is done, pacing retries after a
+// failed run with backoff.Backoff. Any non-nil error from run counts as a
+// failure, including one wrapping source.ErrPartialFetch.
+func (r *Runner) loop(ctx context.Context, period time.Duration, run func(context.Context) (match.Stats, error)) {
+	b := backoff.Backoff{Period: period, MaxBackoffExponent: 6}
+	for {
+		b.Wait()
+		if ctx.Err() != nil {
+			return
+		}
+
+		b.StartRun()
+		if _, err := run(ctx); err != nil {
+			next := b.EndRun(backoff.Failure)
+			slog.Error("Feed refresh failed", "error", err, "next_try", next)
+		} else {
+			b.EndRun(backoff.Success)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/mcsv"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/time2"
@@ -54,14 +55,30 @@ func (e ErrGTFSInvalidPLKTripID) Error() string {
 	return fmt.Sprintf("failed to extract agency, scheduleId and orderId from trip_id %q", string(e))
 }
 
-func LoadGTFSFromPath(path string) (*Package, error) {
+// wrapGTFSFieldError turns a *mcsv.ErrField - as returned by mcsv.Unmarshal
+// and its generic counterparts - into an ErrGTFSInvalidValue for file,
+// preserving the offending column and line; any other error is just
+// prefixed with file, matching the non-struct-tag loaders below.
+func wrapGTFSFieldError(file string, err error) error {
+	var fieldErr *mcsv.ErrField
+	if errors.As(err, &fieldErr) {
+		return ErrGTFSInvalidValue{file, fieldErr.Column, fieldErr.Line, fieldErr.Err}
+	}
+	return fmt.Errorf("%s: %w", file, err)
+}
+
+// LoadGTFSFromPath loads a Package from a GTFS feed at path (either a
+// directory or a .zip archive). loc is the operational railway day's
+// timezone (see Package.Location); pass time2.PolishTimezone unless the
+// operator configured something else via -tz/TZ.
+func LoadGTFSFromPath(path string, loc *time.Location) (*Package, error) {
 	stat, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
 
 	if stat.IsDir() {
-		return LoadGTFS(os.DirFS(path))
+		return LoadGTFS(os.DirFS(path), loc)
 	}
 
 	arch, err := zip.OpenReader(path)
@@ -69,11 +86,11 @@ func LoadGTFSFromPath(path string) (*Package, error) {
 		return nil, err
 	}
 
-	return LoadGTFS(arch)
+	return LoadGTFS(arch, loc)
 }
 
-func LoadGTFS(gtfs fs.FS) (p *Package, err error) {
-	p = new(Package)
+func LoadGTFS(gtfs fs.FS, loc *time.Location) (p *Package, err error) {
+	p = &Package{Location: loc}
 
 	// 1. Load feed_info.txt
 	{
@@ -147,6 +164,10 @@ func LoadGTFS(gtfs fs.FS) (p *Package, err error) {
 		}
 	}
 
+	// 6. Build the TripsByNumber index used by LookupByNumber to
+	// disambiguate same-numbered trips.
+	p.RebuidNumberIndex()
+
 	return
 }
 
@@ -235,36 +256,39 @@ func rankStopID(id string) int {
 	return 0
 }
 
+// calendarDateRow is calendar_dates.txt decoded via mcsv struct tags; Date's
+// time2.Date.UnmarshalText is picked up automatically, via
+// encoding.TextUnmarshaler, to decode it.
+type calendarDateRow struct {
+	ServiceID     string     `mcsv:"service_id"`
+	Date          time2.Date `mcsv:"date"`
+	ExceptionType string     `mcsv:"exception_type"`
+}
+
 func LoadGTFSServices(calendarDates io.Reader, period FeedDates) (map[string][]DatePair, error) {
 	d := make(map[string][]DatePair)
 
 	r := mcsv.NewReader(calendarDates)
-	for row := range r.Iter() {
-		if row["exception_type"] != "1" {
-			panic("GTFS calendar_dates.txt removes dates. This indicates usage of calendar.txt, which is unsupported.")
+	for row, err := range mcsv.IterInto[calendarDateRow](r) {
+		if err != nil {
+			return nil, wrapGTFSFieldError("calendar_dates.txt", err)
 		}
 
-		id := row["service_id"]
-		if id == "" {
-			return nil, ErrGTFSInvalidValue{"calendar_dates.txt", "service_id", r.Line(), nil}
+		if row.ExceptionType != "1" {
+			panic("GTFS calendar_dates.txt removes dates. This indicates usage of calendar.txt, which is unsupported.")
 		}
 
-		var gtfsDate time2.Date
-		err := gtfsDate.UnmarshalText([]byte(row["date"]))
-		if err != nil {
-			return nil, ErrGTFSInvalidValue{"calendar_dates.txt", "date", r.Line(), err}
+		if row.ServiceID == "" {
+			return nil, ErrGTFSInvalidValue{"calendar_dates.txt", "service_id", r.Line(), nil}
 		}
 
-		if period.Contains(gtfsDate) {
-			gtfsOffset := extractStartDateOffset(id)
-			plkDate := gtfsDate.Shifted(-gtfsOffset)
-			d[id] = append(d[id], DatePair{gtfsDate, plkDate})
+		if period.Contains(row.Date) {
+			gtfsOffset := extractStartDateOffset(row.ServiceID)
+			plkDate := row.Date.Shifted(-gtfsOffset)
+			d[row.ServiceID] = append(d[row.ServiceID], DatePair{row.Date, plkDate})
 		}
 	}
 
-	if err := r.Err(); err != nil {
-		return nil, fmt.Errorf("calendar_dates.txt: %w", err)
-	}
 	return d, nil
 }
 
@@ -340,12 +364,66 @@ func extractTrainNumber(tripsRow map[string]string) string {
 	return m
 }
 
+// parseGTFSTimeOfDay parses a GTFS HH:MM:SS time-of-day value into seconds
+// past midnight. HH may exceed 23 for service continuing past midnight. An
+// empty s (a blank, non-timepoint column) returns -1 rather than an error.
+func parseGTFSTimeOfDay(s string) (int, error) {
+	if s == "" {
+		return -1, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("expected HH:MM:SS, got %q", s)
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, err
+	}
+
+	return hours*3600 + minutes*60 + seconds, nil
+}
+
+// gtfsTimeOfDay adapts parseGTFSTimeOfDay to mcsv.Decoder, so arrival_time
+// and departure_time can be picked up by stopTimeRow without a bespoke kind
+// in the mcsv package for GTFS's beyond-23h time-of-day format.
+type gtfsTimeOfDay int
+
+func (t *gtfsTimeOfDay) DecodeMCSV(s string) error {
+	v, err := parseGTFSTimeOfDay(s)
+	*t = gtfsTimeOfDay(v)
+	return err
+}
+
+// stopTimeRow is stop_times.txt decoded via mcsv struct tags.
+type stopTimeRow struct {
+	TripID        string        `mcsv:"trip_id"`
+	StopID        string        `mcsv:"stop_id"`
+	GTFSSequence  int           `mcsv:"stop_sequence"`
+	PLKSequence   int           `mcsv:"plk_sequence"`
+	ArrivalTime   gtfsTimeOfDay `mcsv:"arrival_time"`
+	DepartureTime gtfsTimeOfDay `mcsv:"departure_time"`
+}
+
 func LoadGTFSStopTimes(stopTimes io.Reader, tripIDs map[string][]TripID, tripObjects map[TripID]*Trip, canonicalStops map[string]string) error {
 	r := mcsv.NewReader(stopTimes)
-	for row := range r.Iter() {
+	for row, err := range mcsv.IterInto[stopTimeRow](r) {
+		if err != nil {
+			return wrapGTFSFieldError("stop_times.txt", err)
+		}
+
 		var st StopTime
 
-		st.GTFSTripID = row["trip_id"]
+		st.GTFSTripID = row.TripID
 		if st.GTFSTripID == "" {
 			return ErrGTFSInvalidValue{"stop_times.txt", "trip_id", r.Line(), nil}
 		}
@@ -356,7 +434,7 @@ func LoadGTFSStopTimes(stopTimes io.Reader, tripIDs map[string][]TripID, tripObj
 		}
 
 		// Get a canonical stop_id
-		st.StopID = row["stop_id"]
+		st.StopID = row.StopID
 		if st.StopID == "" {
 			return ErrGTFSInvalidValue{"stop_times.txt", "stop_id", r.Line(), nil}
 		}
@@ -364,18 +442,12 @@ func LoadGTFSStopTimes(stopTimes io.Reader, tripIDs map[string][]TripID, tripObj
 			st.StopID = override
 		}
 
-		// Parse gtfs sequence
-		var err error
-		st.GTFSSequence, err = strconv.Atoi(row["stop_sequence"])
-		if err != nil {
-			return ErrGTFSInvalidValue{"stop_times.txt", "stop_sequence", r.Line(), err}
-		}
+		st.GTFSSequence = row.GTFSSequence
+		st.PLKSequence = row.PLKSequence
 
-		// Parse PLK sequence
-		st.PLKSequence, err = strconv.Atoi(row["plk_sequence"])
-		if err != nil {
-			return ErrGTFSInvalidValue{"stop_times.txt", "plk_sequence", r.Line(), err}
-		}
+		// The scheduled times, used to compute StopTimeUpdate delays
+		st.ScheduledArrival = int(row.ArrivalTime)
+		st.ScheduledDeparture = int(row.DepartureTime)
 
 		// Save the stop_time to all possible trips
 		for _, tripID := range tripIDs[st.GTFSTripID] {
@@ -383,10 +455,6 @@ func LoadGTFSStopTimes(stopTimes io.Reader, tripIDs map[string][]TripID, tripObj
 		}
 	}
 
-	if err := r.Err(); err != nil {
-		return fmt.Errorf("stop_times.txt: %w", err)
-	}
-
 	// Ensure all Trip.StopTimes are sorted by sequence
 	for _, o := range tripObjects {
 		slices.SortFunc(o.StopTimes, func(a, b StopTime) int { return cmp.Compare(a.GTFSSequence, b.GTFSSequence) })
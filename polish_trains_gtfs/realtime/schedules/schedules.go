@@ -6,6 +6,7 @@ package schedules
 import (
 	"iter"
 	"slices"
+	"time"
 
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/set"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/time2"
@@ -74,34 +75,117 @@ type StopTime struct {
 	PLKSequence  int
 	Platform     string
 	Track        string
+
+	// ScheduledArrival and ScheduledDeparture are the static
+	// arrival_time/departure_time from stop_times.txt, in seconds past
+	// midnight of the trip's GTFSStartDate; they may exceed 86400 for
+	// trips running past midnight. -1 means the feed left the column
+	// blank, e.g. for a non-timepoint stop.
+	ScheduledArrival   int
+	ScheduledDeparture int
 }
 
 type Package struct {
 	Dates                 FeedDates
 	Stops                 map[string]string
 	Trips                 map[TripID]*Trip
-	TripsByNumber         map[NumberID]*Trip
+	TripsByNumber         map[NumberID][]*Trip
 	AlternativeTripLookup map[TripID]NumberID
+
+	// Location is the operational railway day's timezone (Europe/Warsaw
+	// unless overridden), used whenever a Date needs to become a concrete
+	// instant in time, e.g. via Date.StartOfDay.
+	Location *time.Location
 }
 
 func (p *Package) RebuidNumberIndex() {
 	if p.TripsByNumber == nil {
-		p.TripsByNumber = make(map[NumberID]*Trip)
+		p.TripsByNumber = make(map[NumberID][]*Trip)
 	} else {
 		clear(p.TripsByNumber)
 	}
 
 	for _, trip := range p.Trips {
 		for number := range trip.GetNumberIDs() {
-			_, exists := p.TripsByNumber[number]
-			if exists {
-				// If `number` is not unique, set its value to `nil` in the lookup table.
-				// This prevents the key from being used during matching, but also
-				// makes further duplicates are also not remembered (which would happen with `delete`).
-				p.TripsByNumber[number] = nil
-			} else {
-				p.TripsByNumber[number] = trip
-			}
+			p.TripsByNumber[number] = append(p.TripsByNumber[number], trip)
+		}
+	}
+}
+
+// LookupHint narrows down a NumberID lookup among several physical services
+// that happen to share a train number on the same operating day. Fields the
+// caller doesn't know about should be left at their zero value.
+type LookupHint struct {
+	// FirstStopID and LastStopID are canonical GTFS stop ids the caller
+	// observed (or expects) the real-world train to call at first/last.
+	FirstStopID, LastStopID string
+
+	// Date is the calendar date the caller expects the matched Trip to
+	// start on. Left zero (an invalid Date) when unknown.
+	Date time2.Date
+
+	// AgencyID, if set, rules out candidates run by a different carrier.
+	// Usually redundant, since id.AgencyID already pins this down, but kept
+	// as a defensive, independently-sourced check.
+	AgencyID string
+}
+
+// LookupByNumber returns the Trip registered under id, disambiguating
+// between same-numbered trips using hint: an exact first/last stop match
+// wins outright; otherwise the candidate sharing the most stops and start
+// date with hint is returned, provided it agrees with hint on at least one
+// field. Returns nil if id is unknown, or if hint is too weak (or absent)
+// to tell the candidates apart, rather than guessing.
+func (p *Package) LookupByNumber(id NumberID, hint LookupHint) *Trip {
+	candidates := p.TripsByNumber[id]
+	if len(candidates) == 0 {
+		return nil
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	var best *Trip
+	bestScore := 0
+	tied := false
+	for _, candidate := range candidates {
+		if hint.AgencyID != "" && candidate.AgencyID != hint.AgencyID {
+			continue
+		}
+
+		if hint.FirstStopID != "" && hint.LastStopID != "" &&
+			len(candidate.StopTimes) > 0 &&
+			candidate.StopTimes[0].StopID == hint.FirstStopID &&
+			candidate.StopTimes[len(candidate.StopTimes)-1].StopID == hint.LastStopID {
+			return candidate
+		}
+
+		if score := candidateHintScore(candidate, hint); score > bestScore {
+			best, bestScore, tied = candidate, score, false
+		} else if score > 0 && score == bestScore {
+			tied = true
 		}
 	}
+	if tied {
+		return nil
+	}
+	return best
+}
+
+// candidateHintScore counts how many of hint's (known) fields candidate
+// agrees with, acting as a longest-common-stop-subsequence stand-in when
+// hint only carries the endpoints of the real-world run.
+func candidateHintScore(candidate *Trip, hint LookupHint) int {
+	score := 0
+	stopIDs := candidate.GetStopIDs()
+	if hint.FirstStopID != "" && stopIDs.Has(hint.FirstStopID) {
+		score++
+	}
+	if hint.LastStopID != "" && stopIDs.Has(hint.LastStopID) {
+		score++
+	}
+	if hint.Date.IsValid() && candidate.GTFSStartDate == hint.Date {
+		score++
+	}
+	return score
 }
@@ -0,0 +1,379 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+// Package serve exposes the latest fetched fact.Container over HTTP, as an
+// alternative (or addition) to writing it to disk.
+package serve
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/match"
+	"google.golang.org/protobuf/proto"
+)
+
+// Server keeps the latest fetched fact.Container of each feed in memory and
+// serves them on demand, rather than requiring consumers to poll a file on
+// disk. Trip updates and alerts are tracked independently, since -serve
+// refreshes them on their own schedules in the same process.
+type Server struct {
+	tripUpdates         atomic.Pointer[fact.Container] // current trip updates
+	previousTripUpdates atomic.Pointer[fact.Container] // tripUpdates' predecessor, for the DIFFERENTIAL feed
+	alerts              atomic.Pointer[fact.Container] // current alerts
+	previousAlerts      atomic.Pointer[fact.Container] // alerts' predecessor, for the DIFFERENTIAL feed
+	vehicles            atomic.Pointer[fact.Container] // companion VehiclePositions, when -vehicles is set
+
+	tripUpdateStats atomic.Pointer[match.Stats]
+	alertStats      atomic.Pointer[match.Stats]
+	backoffFailures atomic.Uint64
+
+	// Period is how often the feed is expected to be refreshed; it drives
+	// the Cache-Control header and the /healthz staleness check.
+	Period time.Duration
+
+	// StaleAfterPeriods is how many missed Periods are tolerated before
+	// /healthz reports unhealthy (503). Zero disables the check.
+	StaleAfterPeriods int
+
+	// Archiver, when set, backs GET /archive/index.json and
+	// GET /archive/{timestamp}.pb.
+	Archiver *fact.Archiver
+}
+
+func NewServer(period time.Duration, staleAfterPeriods int) *Server {
+	return &Server{Period: period, StaleAfterPeriods: staleAfterPeriods}
+}
+
+// UpdateTripUpdates publishes a freshly fetched trip-updates container,
+// replacing whatever was served before. The previous container is kept
+// around for GET /trip-updates-diff.pb. Safe to call concurrently with
+// ServeHTTP.
+func (s *Server) UpdateTripUpdates(c *fact.Container) {
+	if old := s.tripUpdates.Load(); old != nil {
+		s.previousTripUpdates.Store(old)
+	}
+	s.tripUpdates.Store(c)
+}
+
+// UpdateAlerts publishes a freshly fetched alerts container, replacing
+// whatever was served before. The previous container is kept around for GET
+// /alerts-diff.pb. Safe to call concurrently with ServeHTTP.
+func (s *Server) UpdateAlerts(c *fact.Container) {
+	if old := s.alerts.Load(); old != nil {
+		s.previousAlerts.Store(old)
+	}
+	s.alerts.Store(c)
+}
+
+// UpdateVehicles publishes a freshly fetched VehiclePositions container,
+// served from GET /vehicles.pb. Safe to call concurrently with ServeHTTP.
+func (s *Server) UpdateVehicles(c *fact.Container) {
+	s.vehicles.Store(c)
+}
+
+// UpdateTripUpdateStats records the match.Stats of the most recent
+// trip-updates run, reported by GET /metrics and GET /health. Safe to call
+// concurrently with ServeHTTP.
+func (s *Server) UpdateTripUpdateStats(stats match.Stats) {
+	s.tripUpdateStats.Store(&stats)
+}
+
+// UpdateAlertStats records the match.Stats of the most recent alerts run,
+// reported by GET /metrics and GET /health. Safe to call concurrently with
+// ServeHTTP.
+func (s *Server) UpdateAlertStats(stats match.Stats) {
+	s.alertStats.Store(&stats)
+}
+
+// UpdateBackoffFailures records the consecutive-failure count of the run
+// loop's backoff.Backoff, reported by GET /metrics. Safe to call
+// concurrently with ServeHTTP.
+func (s *Server) UpdateBackoffFailures(failures uint) {
+	s.backoffFailures.Store(uint64(failures))
+}
+
+// Mux returns the HTTP routes backed by this Server: /trip-updates.pb,
+// /trip-updates.json, /trip-updates-diff.pb, /alerts.pb, /alerts.json,
+// /alerts-diff.pb, /vehicles.pb, /health, /healthz, /metrics and, when
+// Archiver is set, /archive/index.json and /archive/{timestamp}.pb.
+func (s *Server) Mux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /trip-updates.pb", s.handleTripUpdatesProtobuf)
+	mux.HandleFunc("GET /trip-updates.json", s.handleTripUpdatesJSON)
+	mux.HandleFunc("GET /trip-updates-diff.pb", s.handleTripUpdatesDiff)
+	mux.HandleFunc("GET /alerts.pb", s.handleAlertsProtobuf)
+	mux.HandleFunc("GET /alerts.json", s.handleAlertsJSON)
+	mux.HandleFunc("GET /alerts-diff.pb", s.handleAlertsDiff)
+	mux.HandleFunc("GET /vehicles.pb", s.handleVehicles)
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /archive/index.json", s.handleArchiveIndex)
+	mux.HandleFunc("GET /archive/", s.handleArchiveEntry)
+	return mux
+}
+
+func (s *Server) handleTripUpdatesProtobuf(w http.ResponseWriter, r *http.Request) {
+	serveProtobuf(w, r, s, &s.tripUpdates)
+}
+
+func (s *Server) handleTripUpdatesJSON(w http.ResponseWriter, r *http.Request) {
+	serveJSON(w, r, s, &s.tripUpdates)
+}
+
+func (s *Server) handleTripUpdatesDiff(w http.ResponseWriter, r *http.Request) {
+	serveDiff(w, r, s, &s.tripUpdates, &s.previousTripUpdates)
+}
+
+func (s *Server) handleAlertsProtobuf(w http.ResponseWriter, r *http.Request) {
+	serveProtobuf(w, r, s, &s.alerts)
+}
+
+func (s *Server) handleAlertsJSON(w http.ResponseWriter, r *http.Request) {
+	serveJSON(w, r, s, &s.alerts)
+}
+
+func (s *Server) handleAlertsDiff(w http.ResponseWriter, r *http.Request) {
+	serveDiff(w, r, s, &s.alerts, &s.previousAlerts)
+}
+
+func serveProtobuf(w http.ResponseWriter, r *http.Request, s *Server, feed *atomic.Pointer[fact.Container]) {
+	c := feed.Load()
+	if c == nil {
+		http.Error(w, "no data fetched yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := c.DumpGTFS(&buf, fact.Binary); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.serveBody(w, r, c.Timestamp, "application/x-protobuf", buf.Bytes())
+}
+
+func serveJSON(w http.ResponseWriter, r *http.Request, s *Server, feed *atomic.Pointer[fact.Container]) {
+	c := feed.Load()
+	if c == nil {
+		http.Error(w, "no data fetched yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := c.DumpJSON(&buf, fact.HumanReadable); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.serveBody(w, r, c.Timestamp, "application/json", buf.Bytes())
+}
+
+// serveDiff serves *feed as a GTFS-RT DIFFERENTIAL update against
+// *previous, so that clients that already hold the previous snapshot don't
+// need to re-download unchanged entities.
+func serveDiff(w http.ResponseWriter, r *http.Request, s *Server, feed, previous *atomic.Pointer[fact.Container]) {
+	c := feed.Load()
+	if c == nil {
+		http.Error(w, "no data fetched yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := proto.Marshal(c.AsGTFSDiff(previous.Load()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.serveBody(w, r, c.Timestamp, "application/x-protobuf", body)
+}
+
+func (s *Server) handleVehicles(w http.ResponseWriter, r *http.Request) {
+	c := s.vehicles.Load()
+	if c == nil {
+		http.Error(w, "no vehicle positions fetched yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := c.DumpGTFS(&buf, fact.Binary); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.serveBody(w, r, c.Timestamp, "application/x-protobuf", buf.Bytes())
+}
+
+// handleMetrics reports Prometheus-style gauges derived from the most
+// recent match.Stats of each feed and the run loop's backoff.Backoff, so
+// operators can alert on e.g. a spike in the unmatched ratio or repeated
+// fetch failures.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if stats := s.tripUpdateStats.Load(); stats != nil {
+		fmt.Fprintf(w, "gtfs_rt_matched_total{feed=\"trip_updates\"} %d\n", stats.Matched)
+		fmt.Fprintf(w, "gtfs_rt_unmatched_total{feed=\"trip_updates\"} %d\n", stats.Unmatched)
+		fmt.Fprintf(w, "gtfs_rt_outside_feed_dates_total{feed=\"trip_updates\"} %d\n", stats.OutsideFeedDates)
+	}
+	if stats := s.alertStats.Load(); stats != nil {
+		fmt.Fprintf(w, "gtfs_rt_matched_total{feed=\"alerts\"} %d\n", stats.Matched)
+		fmt.Fprintf(w, "gtfs_rt_unmatched_total{feed=\"alerts\"} %d\n", stats.Unmatched)
+		fmt.Fprintf(w, "gtfs_rt_outside_feed_dates_total{feed=\"alerts\"} %d\n", stats.OutsideFeedDates)
+	}
+
+	fmt.Fprintf(w, "gtfs_rt_backoff_failures %d\n", s.backoffFailures.Load())
+}
+
+// feedHealth is the /health and /healthz JSON shape reported for a single
+// feed.
+type feedHealth struct {
+	LastFetch time.Time    `json:"last_fetch"`
+	Stale     bool         `json:"stale"`
+	Stats     *match.Stats `json:"stats,omitempty"`
+}
+
+func (s *Server) feedHealth(feed *atomic.Pointer[fact.Container], stats *atomic.Pointer[match.Stats]) (feedHealth, bool) {
+	c := feed.Load()
+	if c == nil {
+		return feedHealth{}, false
+	}
+
+	stale := s.StaleAfterPeriods > 0 && s.Period > 0 &&
+		time.Since(c.Timestamp) > time.Duration(s.StaleAfterPeriods)*s.Period
+	return feedHealth{LastFetch: c.Timestamp, Stale: stale, Stats: stats.Load()}, true
+}
+
+// handleHealthz is a minimal, trip-updates-only health check kept for
+// existing orchestration configured against it; new integrations should use
+// GET /health instead.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	health, ok := s.feedHealth(&s.tripUpdates, &s.tripUpdateStats)
+	if !ok {
+		http.Error(w, "no data fetched yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	if health.Stale {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"last_fetch": health.LastFetch,
+		"stale":      health.Stale,
+	})
+}
+
+// handleHealth reports last-refresh time, staleness and match.Stats for
+// both feeds, so a single probe can cover the whole -serve daemon.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	tripUpdates, haveTripUpdates := s.feedHealth(&s.tripUpdates, &s.tripUpdateStats)
+	alerts, haveAlerts := s.feedHealth(&s.alerts, &s.alertStats)
+
+	if !haveTripUpdates && !haveAlerts {
+		http.Error(w, "no data fetched yet", http.StatusServiceUnavailable)
+		return
+	}
+	if tripUpdates.Stale || alerts.Stale {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]feedHealth{
+		"trip_updates": tripUpdates,
+		"alerts":       alerts,
+	})
+}
+
+func (s *Server) handleArchiveIndex(w http.ResponseWriter, r *http.Request) {
+	if s.Archiver == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	entries, err := s.Archiver.List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (s *Server) handleArchiveEntry(w http.ResponseWriter, r *http.Request) {
+	if s.Archiver == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/archive/")
+	name = strings.TrimSuffix(name, ".pb")
+	ts, err := time.ParseInLocation(fact.ArchiveTimeFormat, name, time.UTC)
+	if err != nil {
+		http.Error(w, "invalid timestamp, expected "+fact.ArchiveTimeFormat+".pb", http.StatusBadRequest)
+		return
+	}
+
+	snapshot, err := s.Archiver.Open(ts)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer snapshot.Close()
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	io.Copy(w, snapshot)
+}
+
+// serveBody writes body honoring If-None-Match and If-Modified-Since (in
+// that order, per RFC 9110) and Accept-Encoding: gzip, and sets
+// ETag/Last-Modified/Cache-Control from modTime and s.Period. The ETag is a
+// strong tag derived from modTime, which is unique per fetched container.
+func (s *Server) serveBody(w http.ResponseWriter, r *http.Request, modTime time.Time, contentType string, body []byte) {
+	modTime = modTime.UTC().Truncate(time.Second)
+	etag := fmt.Sprintf(`"%d"`, modTime.Unix())
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+	if s.Period > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int((s.Period/2).Seconds())))
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if match == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modTime.After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		defer gw.Close()
+		gw.Write(body)
+		return
+	}
+
+	w.Write(body)
+}
@@ -5,6 +5,7 @@ package source
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -21,6 +22,7 @@ type Disruption struct {
 	Type           string           `json:"tc"`
 	Title          string           `json:"tt"`
 	Message        string           `json:"msg"`
+	ActivePeriod   Period           `json:"ap"`
 	AffectedTrains []*AffectedTrain `json:"ar"`
 }
 
@@ -30,10 +32,45 @@ type AffectedTrain struct {
 	Sequence  int `json:"seq"`
 }
 
-func FetchDisruptions(ctx context.Context, apikey string, client *http.Client) (d *Disruptions, err error) {
+// FetchDisruptions fetches the disruptions endpoint, retrying up to
+// options.Retries times (paced by a backoff.Backoff) after a transient
+// (429/503) failure. The whole call is bounded by options.Timeout, and each
+// attempt by options.AttemptTimeout, so a stuck request can't hang the
+// fetch forever.
+func FetchDisruptions(ctx context.Context, apikey string, client *http.Client, options FetchOptions) (*Disruptions, error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	var result *Disruptions
+	err := retryTransient(ctx, options.Retries, func(ctx context.Context) error {
+		attemptCtx := ctx
+		if options.AttemptTimeout > 0 {
+			var cancel context.CancelFunc
+			attemptCtx, cancel = context.WithTimeout(ctx, options.AttemptTimeout)
+			defer cancel()
+		}
+
+		slog.Debug("Fetching disruptions")
+		d, err := fetchDisruptionsOnce(attemptCtx, apikey, client)
+		if err != nil {
+			return err
+		}
+		result = d
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func fetchDisruptionsOnce(ctx context.Context, apikey string, client *http.Client) (*Disruptions, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", "https://pdp-api.plk-sa.pl/api/v1/disruptions/shortened", nil)
 	if err != nil {
-		return
+		return nil, err
 	}
 	req.Header.Set("X-Api-Key", apikey)
 
@@ -6,22 +6,37 @@ package source
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/backoff"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/http2"
 	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/time2"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
 )
 
 const DefaultPageSize = 5000
 const DefaultMaxPages = 10
 const DefaultFetchSpacing = 100 * time.Millisecond
+const DefaultConcurrency = 4
+const DefaultRetries = 3
+const DefaultFetchTimeout = 45 * time.Second
+const DefaultAttemptTimeout = 10 * time.Second
 
 var ErrTooManyPages = errors.New("fetching operations takes too many pages")
 
+// ErrPartialFetch wraps the context error that cut a FetchOperations call
+// short of its FetchOptions.Timeout deadline. FetchOperations still returns
+// whatever pages it collected alongside this error, so the caller can
+// decide whether a partial snapshot is worth publishing rather than losing
+// the whole poll.
+var ErrPartialFetch = errors.New("fetch deadline expired before every page was fetched")
+
 type Operations struct {
 	Timestamp time.Time         `json:"ts"`
 	Pages     Pagination        `json:"pg"`
@@ -44,51 +59,199 @@ type OperationTrainStop struct {
 	Cancelled       bool            `json:"cn"`
 }
 
-type PageFetchOptions struct {
+// FetchOptions tunes FetchOperations and FetchDisruptions: how many pages
+// (operations only) and how many times a single HTTP attempt is retried,
+// and the deadlines that keep a stuck PKP PLK API call from hanging the
+// whole poll forever.
+type FetchOptions struct {
 	PageSize     int
 	MaxPages     int
 	FetchSpacing time.Duration
+
+	// Concurrency bounds how many pages beyond the first are fetched at
+	// once; FetchSpacing still applies as one rate limit shared across all
+	// of them: on average one request per FetchSpacing, with bursts of up to
+	// Concurrency requests allowed.
+	Concurrency int
+
+	// Retries is how many times a single HTTP attempt (one operations page,
+	// or the one disruptions request) is retried after a transient
+	// (429/503) failure before the fetch gives up on it.
+	Retries int
+
+	// Timeout bounds the entire fetch - every page and every retry
+	// combined. On expiry, FetchOperations returns whatever pages it
+	// already collected alongside ErrPartialFetch, rather than losing the
+	// whole poll to one slow page. Zero disables the deadline.
+	Timeout time.Duration
+
+	// AttemptTimeout bounds a single HTTP request - one page, one retry -
+	// via a context derived from the one passed to FetchOperations /
+	// FetchDisruptions, so one slow request only fails that attempt
+	// instead of hanging the whole fetch. Zero disables the deadline.
+	AttemptTimeout time.Duration
 }
 
-func NewPageFetchOptions() PageFetchOptions {
-	return PageFetchOptions{
-		PageSize:     DefaultPageSize,
-		MaxPages:     DefaultMaxPages,
-		FetchSpacing: DefaultFetchSpacing,
+func NewFetchOptions() FetchOptions {
+	return FetchOptions{
+		PageSize:       DefaultPageSize,
+		MaxPages:       DefaultMaxPages,
+		FetchSpacing:   DefaultFetchSpacing,
+		Concurrency:    DefaultConcurrency,
+		Retries:        DefaultRetries,
+		Timeout:        DefaultFetchTimeout,
+		AttemptTimeout: DefaultAttemptTimeout,
 	}
 }
 
-func FetchOperations(ctx context.Context, apikey string, client *http.Client, options PageFetchOptions) (*Operations, error) {
-	var all *Operations
-	var nextFetch time.Time
+// FetchOperations fetches every page of the operations endpoint and
+// concatenates their Trains into a single Operations snapshot. Page 1 is
+// fetched alone to learn Pages.TotalPages; the remaining pages are then
+// fetched concurrently (bounded by options.Concurrency, rate-limited by
+// options.FetchSpacing) and assembled back in page order, so the resulting
+// Trains slice - and thus entity order in the served feed - doesn't churn
+// between polls just because pages raced to finish.
+//
+// The whole call is bounded by options.Timeout; if it expires before every
+// page is in, FetchOperations returns the pages it did collect alongside an
+// error wrapping ErrPartialFetch, rather than discarding a mostly-complete
+// snapshot.
+func FetchOperations(ctx context.Context, apikey string, client *http.Client, options FetchOptions) (*Operations, error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	limiter := rate.NewLimiter(rate.Every(options.FetchSpacing), max(options.Concurrency, 1))
+
+	first, err := fetchPageWithRetry(ctx, apikey, client, 1, options.PageSize, options.Retries, options.AttemptTimeout, limiter)
+	if err != nil {
+		return nil, err
+	}
+
+	all := &Operations{
+		Timestamp: first.Timestamp,
+		Pages: Pagination{
+			PageSize:     first.Pages.PageSize,
+			TotalPages:   first.Pages.TotalPages,
+			TotalEntries: first.Pages.TotalEntries,
+		},
+	}
+
+	if !first.Pages.HasNext || first.Pages.TotalPages <= 1 {
+		all.Trains = first.Trains
+		return all, nil
+	}
+	if first.Pages.TotalPages > options.MaxPages {
+		return nil, ErrTooManyPages
+	}
+
+	pages := make([][]*OperationTrain, first.Pages.TotalPages)
+	pages[0] = first.Trains
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(options.Concurrency)
+	for page := 2; page <= first.Pages.TotalPages; page++ {
+		g.Go(func() error {
+			o, err := fetchPageWithRetry(gctx, apikey, client, page, options.PageSize, options.Retries, options.AttemptTimeout, limiter)
+			if err != nil {
+				return err
+			}
+			pages[page-1] = o.Trains
+			return nil
+		})
+	}
+	waitErr := g.Wait()
+
+	fetched := 0
+	for _, trains := range pages {
+		if trains != nil {
+			all.Trains = append(all.Trains, trains...)
+			fetched++
+		}
+	}
 
-	for page := 1; page <= options.MaxPages; page++ {
-		waitFor(ctx, nextFetch)
-		slog.Debug("Fetching operations", "page", page)
-		o, err := FetchOperationsPage(ctx, apikey, client, page, options.PageSize)
+	if waitErr != nil {
+		if errors.Is(waitErr, context.DeadlineExceeded) || errors.Is(waitErr, context.Canceled) {
+			return all, fmt.Errorf("%w: got %d/%d pages: %w", ErrPartialFetch, fetched, len(pages), waitErr)
+		}
+		return nil, waitErr
+	}
+
+	return all, nil
+}
+
+// fetchPageWithRetry fetches a single page, retrying up to maxRetries times
+// (with backoff.Backoff-paced waits) on a transient 429/503 response. Each
+// attempt is bounded by attemptTimeout, so one slow request only fails that
+// attempt rather than hanging the whole fetch. limiter paces every attempt
+// - including retries - at FetchSpacing, shared across all concurrent
+// callers.
+func fetchPageWithRetry(ctx context.Context, apikey string, client *http.Client, page, pageSize, maxRetries int, attemptTimeout time.Duration, limiter *rate.Limiter) (*Operations, error) {
+	var result *Operations
+	err := retryTransient(ctx, maxRetries, func(ctx context.Context) error {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		attemptCtx := ctx
+		if attemptTimeout > 0 {
+			var cancel context.CancelFunc
+			attemptCtx, cancel = context.WithTimeout(ctx, attemptTimeout)
+			defer cancel()
+		}
+
+		o, err := FetchOperationsPage(attemptCtx, apikey, client, page, pageSize)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		result = o
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("page %d: %w", page, err)
+	}
+	return result, nil
+}
 
-		if all == nil {
-			all = &Operations{
-				Timestamp: o.Timestamp,
-				Pages: Pagination{
-					PageSize:     o.Pages.PageSize,
-					TotalPages:   o.Pages.TotalPages,
-					TotalEntries: o.Pages.TotalEntries,
-				},
-				Trains: o.Trains,
-			}
-		} else {
-			all.Trains = append(all.Trains, o.Trains...)
+// retryTransient calls attempt, retrying up to maxRetries times (paced by a
+// backoff.Backoff) after a transient (429/503) failure, the same way for
+// every PKP PLK endpoint that wants this behavior.
+func retryTransient(ctx context.Context, maxRetries int, attempt func(ctx context.Context) error) error {
+	var b backoff.Backoff
+
+	for n := 0; ; n++ {
+		b.StartRun()
+		err := attempt(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if !isRetryableError(err) || n >= maxRetries {
+			return err
+		}
+
+		nextTry := b.EndRun(backoff.Failure)
+		slog.Warn("Retrying after transient error", "attempt", n+1, "error", err)
+		if err := waitFor(ctx, nextTry); err != nil {
+			return err
 		}
+	}
+}
 
-		if !o.Pages.HasNext {
-			return all, nil
+// isRetryableError reports whether err is a 429 or 503 response from a PKP
+// PLK endpoint, the two statuses it uses to signal a caller should back off
+// and try again rather than give up outright.
+func isRetryableError(err error) bool {
+	var httpErr *http2.Error
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case 429, 503:
+			return true
 		}
 	}
-	return nil, ErrTooManyPages
+	return false
 }
 
 func FetchOperationsPage(ctx context.Context, apikey string, client *http.Client, page, pageSize int) (o *Operations, err error) {
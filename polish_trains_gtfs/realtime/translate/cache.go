@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package translate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/text/language"
+)
+
+// Cache persists translated strings to disk under Dir, keyed by a SHA-256
+// hash of the target language and source text, so that restarting the
+// realtime loop doesn't re-bill an HTTPProvider's backend for text it has
+// already translated.
+type Cache struct {
+	Dir string
+}
+
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+func (c *Cache) Get(text string, to language.Tag) (string, bool) {
+	b, err := os.ReadFile(filepath.Join(c.Dir, c.key(text, to)))
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+func (c *Cache) Put(text string, to language.Tag, translated string) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(c.Dir, c.key(text, to)), []byte(translated), 0o644)
+}
+
+func (c *Cache) key(text string, to language.Tag) string {
+	h := sha256.Sum256([]byte(to.String() + "\x00" + text))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
+	"golang.org/x/text/language"
+)
+
+// Entry is a single canned translation in a Dictionary, matched against an
+// alert's source text by a case-insensitive prefix: PKP PLK disruption
+// titles/messages are overwhelmingly templated ("Opóźnienie pociągu ...",
+// "Zmiana peronu ..."), so matching a short prefix covers most real text
+// without needing the full string.
+type Entry struct {
+	Prefixes []string          `json:"prefixes"`
+	Texts    map[string]string `json:"texts"` // BCP 47 tag -> translated text
+}
+
+// Dictionary is a static, hand-maintained Provider loaded from a JSON file:
+// a list of Entry, each giving one or more Prefixes and the Texts to use for
+// any source text starting with one of them (case-insensitively). Text that
+// matches no Entry falls back to Fallback, or to the source text alone when
+// Fallback is nil.
+type Dictionary struct {
+	Entries  []Entry
+	Fallback Provider
+}
+
+// LoadDictionary reads a JSON-encoded []Entry from path.
+func LoadDictionary(path string, fallback Provider) (*Dictionary, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+
+	return &Dictionary{Entries: entries, Fallback: fallback}, nil
+}
+
+func (d *Dictionary) Translate(ctx context.Context, text string, from language.Tag, to ...language.Tag) (fact.Translations, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	lower := strings.ToLower(text)
+	for _, e := range d.Entries {
+		for _, prefix := range e.Prefixes {
+			if !strings.HasPrefix(lower, strings.ToLower(prefix)) {
+				continue
+			}
+
+			out := make(fact.Translations, len(e.Texts)+1)
+			out[from] = text
+			for tagStr, translated := range e.Texts {
+				tag, err := language.Parse(tagStr)
+				if err != nil {
+					continue
+				}
+				out[tag] = translated
+			}
+			return out, nil
+		}
+	}
+
+	if d.Fallback != nil {
+		return d.Fallback.Translate(ctx, text, from, to...)
+	}
+	return fact.Translations{from: text}, nil
+}
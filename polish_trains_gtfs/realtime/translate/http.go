@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/util/http2"
+	"golang.org/x/text/language"
+)
+
+// HTTPProvider calls a LibreTranslate-compatible HTTP API (DeepL's own API
+// can be fronted with a thin LibreTranslate-shaped proxy) at BaseURL, set by
+// -translate-api, to translate text into every requested target language.
+// Results are cached in Cache, when set, so repeated alerts with the same
+// templated text don't re-hit the backend. A failure to translate into one
+// target language is logged and skipped rather than failing the whole
+// Translate call; Fallback, when set, is used instead of the bare source
+// text for languages the backend couldn't produce.
+type HTTPProvider struct {
+	BaseURL  string
+	APIKey   string
+	Client   *http.Client
+	Cache    *Cache
+	Fallback Provider
+}
+
+type translateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type translateResponse struct {
+	TranslatedText string `json:"translatedText"`
+}
+
+func (p *HTTPProvider) Translate(ctx context.Context, text string, from language.Tag, to ...language.Tag) (fact.Translations, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	out := fact.Translations{from: text}
+	for _, tag := range to {
+		if tag == from {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return out, ctx.Err()
+		}
+
+		translated, err := p.translateOne(ctx, text, from, tag)
+		if err != nil {
+			if ctx.Err() != nil {
+				return out, ctx.Err()
+			}
+			slog.Warn("Translation request failed", "target", tag, "error", err)
+			if p.Fallback != nil {
+				if fb, err := p.Fallback.Translate(ctx, text, from, tag); err == nil {
+					if t, ok := fb[tag]; ok {
+						out[tag] = t
+					}
+				}
+			}
+			continue
+		}
+
+		out[tag] = translated
+		if p.Cache != nil {
+			if err := p.Cache.Put(text, tag, translated); err != nil {
+				slog.Warn("Failed to write translation cache entry", "target", tag, "error", err)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func (p *HTTPProvider) translateOne(ctx context.Context, text string, from, to language.Tag) (string, error) {
+	if p.Cache != nil {
+		if cached, ok := p.Cache.Get(text, to); ok {
+			return cached, nil
+		}
+	}
+
+	body, err := json.Marshal(translateRequest{
+		Q:      text,
+		Source: from.String(),
+		Target: to.String(),
+		APIKey: p.APIKey,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.BaseURL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http2.GetJSON[translateResponse](p.Client, req)
+	if err != nil {
+		return "", err
+	}
+	return resp.TranslatedText, nil
+}
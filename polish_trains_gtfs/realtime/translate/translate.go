@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+// Package translate turns a single source-language alert text into
+// fact.Translations covering additional target languages, so riders who
+// don't read Polish still get something usable out of the alerts feed.
+package translate
+
+import (
+	"context"
+
+	"github.com/MKuranowski/PolishTrainsGTFS/polish_trains_gtfs/realtime/fact"
+	"golang.org/x/text/language"
+)
+
+// Provider turns text, written in from, into a fact.Translations map that
+// always includes from plus as many of to as the implementation manages to
+// produce. A returned error should mean ctx was cancelled or the backend is
+// unrecoverably broken; a missing target language is not itself an error.
+type Provider interface {
+	Translate(ctx context.Context, text string, from language.Tag, to ...language.Tag) (fact.Translations, error)
+}
+
+// PassThrough returns the source text unchanged, under a single from tag.
+// It never contacts a translation backend and is the default provider when
+// neither -translate-api nor -translate-dict is set.
+type PassThrough struct{}
+
+func (PassThrough) Translate(_ context.Context, text string, from language.Tag, _ ...language.Tag) (fact.Translations, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return fact.Translations{from: text}, nil
+}
@@ -4,11 +4,16 @@
 package mcsv
 
 import (
+	"encoding"
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"io"
 	"iter"
+	"reflect"
 	"slices"
+	"strconv"
+	"time"
 )
 
 type Reader struct {
@@ -16,6 +21,8 @@ type Reader struct {
 	header []string
 	record map[string]string
 	err    error
+
+	plans map[reflect.Type][]fieldPlan
 }
 
 func NewReader(r io.Reader) *Reader {
@@ -87,3 +94,300 @@ func (r *Reader) Line() int {
 	line, _ := r.r.FieldPos(0)
 	return line
 }
+
+// Decoder lets a type take over its own column parsing in Unmarshal,
+// ReadInto and IterInto, for values the built-in string/int/float/bool/time
+// kinds can't express.
+type Decoder interface {
+	DecodeMCSV(s string) error
+}
+
+// ErrField wraps a field-level decoding error with the Reader.Line() and
+// column name it came from, so callers don't need to reconstruct that
+// context themselves.
+type ErrField struct {
+	Line   int
+	Column string
+	Err    error
+}
+
+func (e *ErrField) Error() string {
+	return fmt.Sprintf("line %d: column %q: %s", e.Line, e.Column, e.Err)
+}
+
+func (e *ErrField) Unwrap() error { return e.Err }
+
+// Unmarshal reads the next row and populates v, which must be a pointer to
+// a struct whose fields are tagged `mcsv:"column"`. See parseFieldTag for
+// the full tag syntax.
+func (r *Reader) Unmarshal(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("mcsv: Unmarshal requires a non-nil pointer to a struct, got %T", v)
+	}
+
+	record, err := r.Read()
+	if err != nil {
+		return err
+	}
+
+	plans, err := r.planFor(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	return setFields(rv.Elem(), plans, record, r.Line())
+}
+
+// planFor returns the cached field plan for t, building and caching it on
+// first use. Building the plan is the only reflection-heavy work; every
+// later row for the same struct type is just a slice of setters.
+func (r *Reader) planFor(t reflect.Type) ([]fieldPlan, error) {
+	if plans, ok := r.plans[t]; ok {
+		return plans, nil
+	}
+
+	plans, err := buildPlan(t)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.plans == nil {
+		r.plans = make(map[reflect.Type][]fieldPlan)
+	}
+	r.plans[t] = plans
+	return plans, nil
+}
+
+// fieldPlan is the precomputed work needed to set one struct field from a
+// CSV column: where the field lives (Index, for reflect.Value.FieldByIndex,
+// so embedded structs work), which column feeds it, and how to parse that
+// column's text.
+type fieldPlan struct {
+	index    []int
+	column   string
+	kind     string
+	layout   string
+	optional bool
+}
+
+// buildPlan walks every mcsv-tagged field of t (including ones promoted
+// from embedded structs) into a fieldPlan.
+func buildPlan(t reflect.Type) ([]fieldPlan, error) {
+	var plans []fieldPlan
+	for _, f := range reflect.VisibleFields(t) {
+		raw, ok := f.Tag.Lookup("mcsv")
+		if !ok {
+			continue
+		}
+
+		tag, err := parseFieldTag(raw)
+		if err != nil {
+			return nil, fmt.Errorf("mcsv: field %s: %w", f.Name, err)
+		}
+
+		plans = append(plans, fieldPlan{
+			index:    f.Index,
+			column:   tag.column,
+			kind:     tag.kind,
+			layout:   tag.layout,
+			optional: tag.optional,
+		})
+	}
+	return plans, nil
+}
+
+// knownKinds are the type-hint keywords a tag's second component may carry;
+// a component following the time keyword is taken as its layout, and any
+// other unknown component is an error.
+var knownKinds = map[string]bool{
+	"string": true,
+	"int":    true,
+	"float":  true,
+	"bool":   true,
+	"time":   true,
+}
+
+type fieldTag struct {
+	column   string
+	kind     string
+	layout   string
+	optional bool
+}
+
+// parseFieldTag splits a `mcsv:"..."` tag value into its column name, an
+// optional type-hint keyword (string/int/float/bool/time), an optional time
+// layout (only meaningful with the time keyword), and the omitempty flag,
+// e.g. `mcsv:"stop_lat,float"`, `mcsv:"date,time,2006-01-02"`,
+// `mcsv:"optional,omitempty"`.
+func parseFieldTag(raw string) (fieldTag, error) {
+	parts := splitTag(raw)
+	if len(parts) == 0 || parts[0] == "" {
+		return fieldTag{}, errors.New("empty column name")
+	}
+
+	t := fieldTag{column: parts[0]}
+	for _, p := range parts[1:] {
+		switch {
+		case p == "omitempty":
+			t.optional = true
+		case t.kind == "" && knownKinds[p]:
+			t.kind = p
+		case t.kind == "time" && t.layout == "":
+			t.layout = p
+		default:
+			return fieldTag{}, fmt.Errorf("unexpected tag component %q", p)
+		}
+	}
+	return t, nil
+}
+
+func splitTag(raw string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == ',' {
+			parts = append(parts, raw[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, raw[start:])
+	return parts
+}
+
+func setFields(rv reflect.Value, plans []fieldPlan, record map[string]string, line int) error {
+	for _, p := range plans {
+		if err := setField(rv.FieldByIndex(p.index), record[p.column], p); err != nil {
+			return &ErrField{Line: line, Column: p.column, Err: err}
+		}
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+var decoderType = reflect.TypeFor[Decoder]()
+var timeType = reflect.TypeFor[time.Time]()
+
+// setField parses raw into fv per p: a pointer field is left nil on an
+// empty column, any other optional field is left at its zero value, and
+// everything else is decoded via fv's Decoder or encoding.TextUnmarshaler
+// implementation if it has one, falling back to the int/float/bool/time/
+// string kind named (explicitly or inferred from fv's reflect.Kind).
+func setField(fv reflect.Value, raw string, p fieldPlan) error {
+	isPtr := fv.Kind() == reflect.Pointer
+
+	if raw == "" {
+		if isPtr {
+			fv.SetZero()
+			return nil
+		}
+		if p.optional {
+			return nil
+		}
+	}
+
+	target := fv
+	if isPtr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		target = fv.Elem()
+	}
+
+	addr := target.Addr()
+	if addr.Type().Implements(decoderType) {
+		return addr.Interface().(Decoder).DecodeMCSV(raw)
+	}
+	if addr.Type().Implements(textUnmarshalerType) {
+		return addr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	kind := p.kind
+	if kind == "" {
+		kind = defaultKindFor(target.Kind())
+	}
+
+	switch kind {
+	case "string":
+		target.SetString(raw)
+	case "int":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		target.SetInt(n)
+	case "float":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		target.SetFloat(f)
+	case "bool":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		target.SetBool(b)
+	case "time":
+		if target.Type() != timeType {
+			return fmt.Errorf("the time kind requires a time.Time field, got %s", target.Type())
+		}
+		layout := p.layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		parsed, err := time.Parse(layout, raw)
+		if err != nil {
+			return err
+		}
+		target.Set(reflect.ValueOf(parsed))
+	default:
+		return fmt.Errorf("unsupported field type %s for column %q", target.Type(), p.column)
+	}
+	return nil
+}
+
+func defaultKindFor(k reflect.Kind) string {
+	switch k {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Bool:
+		return "bool"
+	default:
+		return ""
+	}
+}
+
+// ReadInto reads the next row into a freshly zeroed T, the generic
+// counterpart of Reader.Unmarshal for callers that don't already have a
+// struct to populate.
+func ReadInto[T any](r *Reader) (T, error) {
+	var v T
+	err := r.Unmarshal(&v)
+	return v, err
+}
+
+// IterInto is the typed counterpart of Reader.Iter: it yields every row
+// decoded into a T, and, unlike Iter, surfaces the terminal error (if any)
+// as the last yielded pair instead of requiring a separate Reader.Err()
+// check. A clean end of input (io.EOF) simply ends iteration.
+func IterInto[T any](r *Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			v, err := ReadInto[T](r)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					yield(v, err)
+				}
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
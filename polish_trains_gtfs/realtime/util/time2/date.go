@@ -70,6 +70,16 @@ func (d *Date) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// At returns the instant of the given wall-clock time on d, in loc.
+func (d Date) At(hour, min, sec int, loc *time.Location) time.Time {
+	return time.Date(int(d.Y), time.Month(d.M), int(d.D), hour, min, sec, 0, loc)
+}
+
+// StartOfDay returns the instant d begins, in loc.
+func (d Date) StartOfDay(loc *time.Location) time.Time {
+	return d.At(0, 0, 0, loc)
+}
+
 func (d Date) Weekday() time.Weekday {
 	return time.Date(int(d.Y), time.Month(d.M), int(d.D), 12, 0, 0, 0, time.UTC).Weekday()
 }
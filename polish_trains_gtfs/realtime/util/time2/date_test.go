@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package time2
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDate_At_DSTSpringForward pins Date.At around the Europe/Warsaw
+// spring-forward boundary (clocks jump 02:00 -> 03:00 on the last Sunday of
+// March), where the 02:xx wall-clock hour doesn't exist.
+func TestDate_At_DSTSpringForward(t *testing.T) {
+	d := Date{Y: 2026, M: 3, D: 29}
+
+	before := d.At(1, 30, 0, PolishTimezone)
+	if _, offset := before.Zone(); offset != 1*60*60 {
+		t.Errorf("01:30 before spring-forward: got UTC offset %ds, want 3600s", offset)
+	}
+
+	after := d.At(3, 30, 0, PolishTimezone)
+	if _, offset := after.Zone(); offset != 2*60*60 {
+		t.Errorf("03:30 after spring-forward: got UTC offset %ds, want 7200s", offset)
+	}
+
+	if gap := after.Sub(before); gap != 1*time.Hour {
+		t.Errorf("01:30 to 03:30 across spring-forward: got gap %s, want 1h (the missing hour)", gap)
+	}
+}
+
+// TestDate_At_DSTFallBack pins Date.At around the Europe/Warsaw fall-back
+// boundary (clocks repeat 02:00 -> 03:00 -> 02:00 on the last Sunday of
+// October), where the 02:xx wall-clock hour is ambiguous. Date.At, like
+// time.Date, resolves it to the second, standard-time (UTC+1) occurrence.
+func TestDate_At_DSTFallBack(t *testing.T) {
+	d := Date{Y: 2026, M: 10, D: 25}
+
+	before := d.At(2, 30, 0, PolishTimezone)
+	if _, offset := before.Zone(); offset != 1*60*60 {
+		t.Errorf("02:30 fall-back instant: got UTC offset %ds, want 3600s (the standard-time reading)", offset)
+	}
+
+	after := d.At(4, 30, 0, PolishTimezone)
+	if _, offset := after.Zone(); offset != 1*60*60 {
+		t.Errorf("04:30 after fall-back: got UTC offset %ds, want 3600s", offset)
+	}
+
+	if gap := after.Sub(before); gap != 2*time.Hour {
+		t.Errorf("02:30 to 04:30 across fall-back: got gap %s, want 2h (02:30 already past the repeated hour)", gap)
+	}
+}
+
+// TestDate_StartOfDay_UTCMidnight checks StartOfDay picks midnight in loc,
+// not UTC midnight - the two differ by a few hours in Europe/Warsaw, which
+// matters right around UTC midnight, when the local date is already the
+// next day.
+func TestDate_StartOfDay_UTCMidnight(t *testing.T) {
+	d := Date{Y: 2026, M: 7, D: 28}
+
+	got := d.StartOfDay(PolishTimezone)
+	gotUTC := got.UTC()
+
+	// 2026-07-28 is in CEST (UTC+2), so 00:00 local is 2026-07-27 22:00 UTC.
+	want := time.Date(2026, time.July, 27, 22, 0, 0, 0, time.UTC)
+	if !gotUTC.Equal(want) {
+		t.Errorf("StartOfDay(2026-07-28, Warsaw) in UTC = %s, want %s", gotUTC, want)
+	}
+
+	if h, m, s := got.Clock(); h != 0 || m != 0 || s != 0 {
+		t.Errorf("StartOfDay(2026-07-28, Warsaw) wall clock = %02d:%02d:%02d, want 00:00:00", h, m, s)
+	}
+}
+
+// TestDate_At_UTC checks At/StartOfDay round-trip cleanly for the UTC
+// location, where there's no DST to offset wall-clock from UTC.
+func TestDate_At_UTC(t *testing.T) {
+	d := Date{Y: 2026, M: 1, D: 1}
+	got := d.At(0, 0, 0, time.UTC)
+	want := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("At(2026-01-01, 00:00:00, UTC) = %s, want %s", got, want)
+	}
+}
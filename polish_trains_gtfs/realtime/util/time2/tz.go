@@ -5,6 +5,7 @@ package time2
 
 import (
 	"fmt"
+	"os"
 	"time"
 )
 
@@ -17,3 +18,26 @@ func init() {
 		panic(fmt.Errorf("failed to load Europe/Warsaw timezone: %w", err))
 	}
 }
+
+// TodayIn returns the current Date as observed in loc, rather than in the
+// process's local timezone. This matters in Docker/UTC environments, where
+// the operational railway day is Europe/Warsaw, not UTC.
+func TodayIn(loc *time.Location) Date {
+	now := time.Now().In(loc)
+	return Date{Y: uint16(now.Year()), M: uint8(now.Month()), D: uint8(now.Day())}
+}
+
+// LocationFromEnv loads a *time.Location from the TZ environment variable,
+// falling back to PolishTimezone when TZ is unset or names an unknown zone.
+func LocationFromEnv() *time.Location {
+	tz := os.Getenv("TZ")
+	if tz == "" {
+		return PolishTimezone
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return PolishTimezone
+	}
+	return loc
+}
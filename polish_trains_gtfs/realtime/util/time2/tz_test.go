@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2026 Mikołaj Kuranowski
+// SPDX-License-Identifier: MIT
+
+package time2
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTodayIn checks that TodayIn reads the date in loc, not in the
+// process's local timezone, so it stays correct right around UTC midnight
+// when the local date (e.g. Europe/Warsaw) has already rolled over.
+func TestTodayIn(t *testing.T) {
+	now := time.Now()
+
+	for _, loc := range []*time.Location{time.UTC, PolishTimezone} {
+		want := now.In(loc)
+		got := TodayIn(loc)
+		if int(got.Y) != want.Year() || time.Month(got.M) != want.Month() || int(got.D) != want.Day() {
+			t.Errorf("TodayIn(%s) = %s, want %04d-%02d-%02d", loc, got, want.Year(), want.Month(), want.Day())
+		}
+	}
+}
+
+func TestLocationFromEnv_Fallback(t *testing.T) {
+	t.Setenv("TZ", "")
+	if got := LocationFromEnv(); got != PolishTimezone {
+		t.Errorf("LocationFromEnv() with unset TZ = %s, want %s", got, PolishTimezone)
+	}
+
+	t.Setenv("TZ", "not-a-real-timezone")
+	if got := LocationFromEnv(); got != PolishTimezone {
+		t.Errorf("LocationFromEnv() with invalid TZ = %s, want %s", got, PolishTimezone)
+	}
+
+	t.Setenv("TZ", "UTC")
+	if got := LocationFromEnv(); got != time.UTC {
+		t.Errorf("LocationFromEnv() with TZ=UTC = %s, want %s", got, time.UTC)
+	}
+}